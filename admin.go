@@ -0,0 +1,214 @@
+// Copyright 2022 The imkuqin-zw Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zap
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/imkuqin-zw/yggdrasil/pkg/governor"
+	"go.uber.org/zap/zapcore"
+)
+
+const defaultAdminPath = "/zap/loggers"
+
+var adminPaths = struct {
+	mu    sync.Mutex
+	paths map[string]bool
+}{paths: map[string]bool{}}
+
+// serveAdmin registers the admin handler on the governor once per path.
+func serveAdmin(path string) {
+	if path == "" {
+		path = defaultAdminPath
+	}
+	adminPaths.mu.Lock()
+	defer adminPaths.mu.Unlock()
+	if adminPaths.paths[path] {
+		return
+	}
+	adminPaths.paths[path] = true
+	governor.HandleFunc(path, NewAdminHandler().ServeHTTP)
+}
+
+var (
+	errUnknownLogger = errors.New("unknown logger")
+	errMissingLevel  = errors.New("missing level")
+)
+
+type registryKey struct {
+	levels *levels
+	name   string
+}
+
+// registry tracks the root, cloned and named loggers, the named loggers are
+// registered once per name and levels.
+var registry = struct {
+	mu      sync.Mutex
+	nextID  int64
+	keys    map[registryKey]int64
+	loggers map[int64]*Logger
+}{
+	keys:    map[registryKey]int64{},
+	loggers: map[int64]*Logger{},
+}
+
+func register(lg *Logger) {
+	key := registryKey{levels: lg.levels, name: lg.name}
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	if _, ok := registry.keys[key]; ok {
+		return
+	}
+	registry.nextID++
+	registry.keys[key] = registry.nextID
+	registry.loggers[registry.nextID] = lg
+}
+
+// unregister forgets the named logger, or every logger sharing the levels of
+// the root or cloned logger.
+func unregister(lg *Logger) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	for key, id := range registry.keys {
+		if key.levels == lg.levels && (lg.owner || key.name == lg.name) {
+			delete(registry.keys, key)
+			delete(registry.loggers, id)
+		}
+	}
+}
+
+func lookupLogger(id int64) (*Logger, bool) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	lg, ok := registry.loggers[id]
+	return lg, ok
+}
+
+type loggerInfo struct {
+	ID    int64    `json:"id"`
+	Name  string   `json:"name"`
+	Level string   `json:"level"`
+	Sinks []string `json:"sinks"`
+}
+
+func loggerInfos() []loggerInfo {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	infos := make([]loggerInfo, 0, len(registry.loggers))
+	for id, lg := range registry.loggers {
+		infos = append(infos, newLoggerInfo(id, lg))
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].ID < infos[j].ID })
+	return infos
+}
+
+func newLoggerInfo(id int64, lg *Logger) loggerInfo {
+	return loggerInfo{ID: id, Name: lg.name, Level: lg.Level().String(), Sinks: lg.cfg.sinks()}
+}
+
+func (config *Config) sinks() []string {
+	sinks := make([]string, 0, 4)
+	if config.Console.Enable {
+		sinks = append(sinks, "console")
+	}
+	if config.File.Enable {
+		dir, name := config.File.Dir, config.File.Name
+		if dir == "" {
+			dir = defaultFileDir
+		}
+		if name == "" {
+			name = defaultFileName
+		}
+		sinks = append(sinks, "file:"+filepath.Join(dir, name))
+	}
+	if config.Gelf.Enable {
+		sinks = append(sinks, fmt.Sprintf("gelf:%s://%s", config.Gelf.Network, config.Gelf.Address))
+	}
+	if config.Journald.Enable {
+		sinks = append(sinks, "journald:"+config.Journald.SocketPath)
+	}
+	return sinks
+}
+
+type setLevelReq struct {
+	ID    int64          `json:"id"`
+	Level *zapcore.Level `json:"level"`
+	// Expire restores the previous level after the given duration, e.g. "10m".
+	Expire string `json:"expire"`
+}
+
+type adminHandler struct{}
+
+// NewAdminHandler returns a handler listing the live loggers with their
+// levels and sinks on GET, and changing the level of a logger on PUT.
+func NewAdminHandler() http.Handler {
+	return adminHandler{}
+}
+
+func (adminHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		respJSON(w, http.StatusOK, loggerInfos())
+	case http.MethodPut:
+		req := &setLevelReq{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			respErr(w, http.StatusBadRequest, err)
+			return
+		}
+		if req.Level == nil {
+			respErr(w, http.StatusBadRequest, errMissingLevel)
+			return
+		}
+		var expire time.Duration
+		if req.Expire != "" {
+			var err error
+			if expire, err = time.ParseDuration(req.Expire); err != nil {
+				respErr(w, http.StatusBadRequest, err)
+				return
+			}
+		}
+		lg, ok := lookupLogger(req.ID)
+		if !ok {
+			respErr(w, http.StatusNotFound, errUnknownLogger)
+			return
+		}
+		if expire > 0 {
+			lg.ElevateFor(*req.Level, expire)
+		} else {
+			lg.SetLevel(*req.Level)
+		}
+		respJSON(w, http.StatusOK, newLoggerInfo(req.ID, lg))
+	default:
+		w.Header().Set("Allow", "GET, PUT")
+		respErr(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+	}
+}
+
+func respJSON(w http.ResponseWriter, code int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(data)
+}
+
+func respErr(w http.ResponseWriter, code int, err error) {
+	respJSON(w, code, map[string]interface{}{"code": code, "msg": err.Error()})
+}
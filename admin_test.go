@@ -0,0 +1,203 @@
+// Copyright 2022 The imkuqin-zw Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zap
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/imkuqin-zw/yggdrasil/pkg/governor"
+	"github.com/imkuqin-zw/yggdrasil/pkg/logger"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func findLoggerInfo(t *testing.T, h http.Handler, name string, lg *Logger) loggerInfo {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	var infos []loggerInfo
+	if err := json.Unmarshal(rec.Body.Bytes(), &infos); err != nil {
+		t.Fatal(err)
+	}
+	for _, info := range infos {
+		if other, ok := lookupLogger(info.ID); ok && other.levels == lg.levels && info.Name == name {
+			return info
+		}
+	}
+	t.Fatalf("logger %q not listed", name)
+	return loggerInfo{}
+}
+
+func Test_AdminHandler(t *testing.T) {
+	cfg := &Config{}
+	cfg.Console.Enable = true
+	root := cfg.Build()
+	root.SetLevel(zapcore.InfoLevel)
+	named := root.Named("app").Named("billing")
+	clone := root.Clone()
+	h := NewAdminHandler()
+
+	info := findLoggerInfo(t, h, "app.billing", named)
+	if info.Level != "info" || len(info.Sinks) != 1 || info.Sinks[0] != "console" {
+		t.Fatalf("unexpected info: %+v", info)
+	}
+
+	body := fmt.Sprintf(`{"id":%d,"level":"debug","expire":"50ms"}`, info.ID)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/", strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected response: %d %s", rec.Code, rec.Body.String())
+	}
+	if named.Level() != zapcore.DebugLevel || root.Level() != zapcore.InfoLevel {
+		t.Fatalf("level not changed: %v %v", named.Level(), root.Level())
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for named.Level() != zapcore.InfoLevel {
+		if time.Now().After(deadline) {
+			t.Fatal("level not restored")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	info = findLoggerInfo(t, h, "", root)
+	body = fmt.Sprintf(`{"id":%d,"level":"error"}`, info.ID)
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPut, "/", strings.NewReader(body)))
+	if root.Level() != zapcore.ErrorLevel || clone.Level() != zapcore.InfoLevel {
+		t.Fatalf("clone level not isolated: %v %v", root.Level(), clone.Level())
+	}
+}
+
+func registered() int {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	return len(registry.loggers)
+}
+
+func Test_AdminRegistry(t *testing.T) {
+	before := registered()
+	root := NewLogger(&Config{Level: "info"})
+	clones := make([]*Logger, 0, 100)
+	for i := 0; i < 100; i++ {
+		clones = append(clones, root.Named("a").Clone())
+	}
+	if n := registered() - before; n != 102 {
+		t.Fatalf("unexpected registered loggers: %d", n)
+	}
+	for _, clone := range clones {
+		clone.Close()
+	}
+	root.Named("b").Close()
+	if n := registered() - before; n != 2 {
+		t.Fatalf("clones not unregistered: %d", n)
+	}
+	root.Close()
+	if n := registered() - before; n != 0 {
+		t.Fatalf("named loggers not unregistered: %d", n)
+	}
+}
+
+func Test_AdminConfig(t *testing.T) {
+	// The governor mux is global, every run registers its own path.
+	path := fmt.Sprintf("/zap/loggers/%d", time.Now().UnixNano())
+	pattern := func() string {
+		_, pattern := governor.DefaultServeMux.Handler(httptest.NewRequest(http.MethodGet, path, nil))
+		return pattern
+	}
+	cfg := &Config{Level: "info"}
+	cfg.Admin.Path = path
+	NewLogger(cfg).Close()
+	if pattern() == path {
+		t.Fatal("admin handler served without the config")
+	}
+	cfg.Admin.Enable = true
+	NewLogger(cfg).Close()
+	NewLogger(cfg).Close()
+	if pattern() != path {
+		t.Fatal("admin handler not served")
+	}
+}
+
+func Test_CloneSharesSinks(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+	cfg := &Config{}
+	cfg.Gelf.Enable = true
+	cfg.Gelf.Address = pc.LocalAddr().String()
+	cfg.FlightRecorder.Enable = true
+	root := cfg.Build()
+	clone := root.Clone()
+	defer root.Close()
+	defer clone.Close()
+	if clone.rec != root.rec {
+		t.Error("flight recorder not shared")
+	}
+
+	root.Write(logger.LvInfo, "root")
+	clone.Write(logger.LvInfo, "clone")
+	_ = pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+	b := make([]byte, 1<<16)
+	addrs := map[string]bool{}
+	for i := 0; i < 2; i++ {
+		_, addr, err := pc.ReadFrom(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		addrs[addr.String()] = true
+	}
+	if len(addrs) != 1 {
+		t.Errorf("clone opened its own GELF connection: %v", addrs)
+	}
+}
+
+func Test_WriterBuilderClose(t *testing.T) {
+	before := registered()
+	build := logger.GetWriterBuilder("zap")
+	build()
+	build()
+	if n := registered() - before; n != 1 {
+		t.Fatalf("rebuilt writers left registered: %d", n)
+	}
+	built.mu.Lock()
+	built.lg.Close()
+	built.lg = nil
+	built.mu.Unlock()
+}
+
+func Test_CloseRestoresGlobals(t *testing.T) {
+	prev := zap.L()
+	cfg := &Config{Level: "info"}
+	cfg.Globals.ReplaceGlobals = true
+	lg := NewLogger(cfg)
+	if zap.L() == prev {
+		t.Fatal("globals not replaced")
+	}
+	lg.Named("a").Close()
+	if zap.L() == prev {
+		t.Fatal("globals restored by a named logger")
+	}
+	lg.Close()
+	if zap.L() != prev {
+		t.Fatal("globals not restored by Close")
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2022 The imkuqin-zw Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zap
+
+import (
+	"go.uber.org/zap/zapcore"
+)
+
+type CallerConfig struct {
+	// Auto reports the first frame outside of the logger packages as the
+	// caller when AddCaller is set, CallerSkip is ignored.
+	Auto bool
+	// Packages are logger packages added to the known ones, e.g. a wrapper of
+	// the application. A trailing "/..." includes the sub-packages.
+	Packages []string
+	// FunctionKey is the key of the function of the caller, it is set on the
+	// encoders lacking one.
+	FunctionKey string
+}
+
+// loggerPackages returns the known logger packages and the configured ones.
+func (config *Config) loggerPackages() []string {
+	if len(config.Caller.Packages) == 0 {
+		return loggerPackages
+	}
+	packages := make([]string, 0, len(loggerPackages)+len(config.Caller.Packages))
+	packages = append(packages, loggerPackages...)
+	return append(packages, config.Caller.Packages...)
+}
+
+// callerCore annotates the checked entries with the first frame outside of
+// the logger packages.
+type callerCore struct {
+	zapcore.Core
+	packages []string
+}
+
+func newCallerCore(core zapcore.Core, packages []string) zapcore.Core {
+	return &callerCore{Core: core, packages: packages}
+}
+
+func (c *callerCore) With(fields []zapcore.Field) zapcore.Core {
+	return &callerCore{Core: c.Core.With(fields), packages: c.packages}
+}
+
+func (c *callerCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Core.Enabled(ent.Level) {
+		return c.Core.Check(ent, ce)
+	}
+	if frame, ok := caller(1, c.packages); ok {
+		ent.Caller = zapcore.EntryCaller{
+			Defined:  true,
+			PC:       frame.PC,
+			File:     frame.File,
+			Line:     frame.Line,
+			Function: frame.Function,
+		}
+	}
+	return c.Core.Check(ent, ce)
+}
@@ -0,0 +1,49 @@
+// Copyright 2022 The imkuqin-zw Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zap
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/imkuqin-zw/yggdrasil/pkg/logger"
+	"google.golang.org/grpc/grpclog"
+)
+
+func Test_AutoCaller(t *testing.T) {
+	cfg := &Config{AddCaller: true}
+	cfg.Caller.Auto = true
+	lg, lines := newTestLogger(t, cfg)
+	yl := logger.NewLogger(logger.LvDebug, lg)
+
+	lg.Write(logger.LvInfo, "write")
+	yl.InfoField("field")
+	yl.Debugf("format %d", 1)
+	yl.WithFields(logger.String("k", "v")).Warn("with fields")
+	grpclog.SetLoggerV2(NewGrpcLogger(lg, &GrpcLogConfig{}))
+	grpclog.Component("transport").Warningf("component %d", 1)
+
+	entries := lines()
+	if len(entries) != 5 {
+		t.Fatalf("unexpected entries: %q", entries)
+	}
+	for _, line := range entries {
+		got := decodeLine(t, line)
+		if caller, _ := got["caller"].(string); !strings.HasPrefix(filepath.Base(caller), "caller_test.go:") {
+			t.Errorf("unexpected caller of %q: %s", got["msg"], caller)
+		}
+	}
+}
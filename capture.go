@@ -0,0 +1,77 @@
+// Copyright 2022 The imkuqin-zw Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zap
+
+import (
+	"bufio"
+	"io"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	defaultCaptureStdoutLevel = "info"
+
+	defaultCaptureStderrLevel = "error"
+)
+
+type CaptureConfig struct {
+	// StdoutLevel is the level of the lines written to stdout, "info" by default.
+	StdoutLevel string
+	// StderrLevel is the level of the lines written to stderr, "error" by default.
+	StderrLevel string
+}
+
+func (config *CaptureConfig) levels() (stdout, stderr zapcore.Level, err error) {
+	if config.StdoutLevel == "" {
+		config.StdoutLevel = defaultCaptureStdoutLevel
+	}
+	if config.StderrLevel == "" {
+		config.StderrLevel = defaultCaptureStderrLevel
+	}
+	if err = stdout.UnmarshalText([]byte(config.StdoutLevel)); err != nil {
+		return
+	}
+	err = stderr.UnmarshalText([]byte(config.StderrLevel))
+	return
+}
+
+// StopCapture restores the descriptors 1 and 2 redirected by NewLogger, the
+// lines written before are logged when it returns.
+func (lg *Logger) StopCapture() {
+	if lg.stopCapture != nil {
+		lg.stopCapture()
+		lg.stopCapture = nil
+	}
+}
+
+// captureLines writes every line read from r as an entry of the stream.
+func captureLines(r io.Reader, lg *zap.Logger, lv zapcore.Level, stream string) {
+	lg = lg.WithOptions(zap.WithCaller(false)).With(zap.String("stream", stream))
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadString('\n')
+		if line = strings.TrimRight(line, "\r\n"); line != "" {
+			if ce := lg.Check(lv, line); ce != nil {
+				ce.Write()
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
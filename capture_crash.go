@@ -0,0 +1,32 @@
+// Copyright 2022 The imkuqin-zw Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build unix && go1.23
+
+package zap
+
+import (
+	"os"
+	"runtime/debug"
+)
+
+// keepCrashOutput makes the runtime write the unrecovered panics and the
+// fatal errors to f, they would be lost in the capture pipe with the process.
+// It returns a function sending them to the descriptor 2 again.
+func keepCrashOutput(f *os.File) func() {
+	if err := debug.SetCrashOutput(f, debug.CrashOptions{}); err != nil {
+		return func() {}
+	}
+	return func() { _ = debug.SetCrashOutput(nil, debug.CrashOptions{}) }
+}
@@ -0,0 +1,25 @@
+// Copyright 2022 The imkuqin-zw Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build unix && !go1.23
+
+package zap
+
+import "os"
+
+// keepCrashOutput is a no-op, the crash output can only be set apart from the
+// descriptor 2 since go1.23.
+func keepCrashOutput(*os.File) func() {
+	return func() {}
+}
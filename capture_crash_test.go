@@ -0,0 +1,43 @@
+// Copyright 2022 The imkuqin-zw Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build unix && go1.23
+
+package zap
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"testing"
+)
+
+func Test_CaptureCrashOutput(t *testing.T) {
+	if os.Getenv("CAPTURE_CRASH") == "1" {
+		cfg := &Config{}
+		cfg.Capture.Enable = true
+		newTestLogger(t, cfg)
+		panic("crashed while captured")
+	}
+	cmd := exec.Command(os.Args[0], "-test.run=^Test_CaptureCrashOutput$")
+	cmd.Env = append(os.Environ(), "CAPTURE_CRASH=1")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err == nil {
+		t.Fatal("process did not crash")
+	}
+	if !bytes.Contains(stderr.Bytes(), []byte("panic: crashed while captured")) {
+		t.Fatalf("crash output lost: %q", stderr.String())
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2022 The imkuqin-zw Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build unix
+
+package zap
+
+import (
+	"fmt"
+	"os"
+	"testing"
+)
+
+func Test_CaptureStdio(t *testing.T) {
+	cfg := &Config{}
+	cfg.Capture.Enable = true
+	cfg.Capture.StdoutLevel = "warn"
+	lg, lines := newTestLogger(t, cfg)
+
+	fmt.Fprintln(os.Stdout, "to stdout")
+	fmt.Fprint(os.Stderr, "to stderr\nwithout newline")
+	lg.StopCapture()
+	fmt.Fprintln(os.Stdout, "not captured")
+
+	want := map[string]struct {
+		lv     string
+		stream string
+	}{
+		"to stdout":       {"warn", "stdout"},
+		"to stderr":       {"error", "stderr"},
+		"without newline": {"error", "stderr"},
+	}
+	for _, line := range lines() {
+		got := decodeLine(t, line)
+		msg, _ := got["msg"].(string)
+		if msg == "not captured" {
+			t.Error("lines captured after StopCapture")
+		}
+		w, ok := want[msg]
+		if !ok {
+			continue
+		}
+		if got["lv"] != w.lv || got["stream"] != w.stream {
+			t.Errorf("unexpected entry: %v", got)
+		}
+		delete(want, msg)
+	}
+	if len(want) != 0 {
+		t.Fatalf("lines not captured: %v", want)
+	}
+}
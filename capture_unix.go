@@ -0,0 +1,95 @@
+// Copyright 2022 The imkuqin-zw Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build unix
+
+package zap
+
+import (
+	"os"
+	"sync"
+
+	"go.uber.org/zap/zapcore"
+	"golang.org/x/sys/unix"
+)
+
+var (
+	captureMu sync.Mutex
+	// stdout and stderr duplicate the original descriptors while captured.
+	stdout, stderr *os.File
+)
+
+// stdio returns the files the console sink writes to, the original
+// descriptors are duplicated when the capture is enabled.
+func stdio(cfg *Config) (*os.File, *os.File) {
+	if !cfg.Capture.Enable {
+		return os.Stdout, os.Stderr
+	}
+	captureMu.Lock()
+	defer captureMu.Unlock()
+	if stdout == nil {
+		stdout, stderr = dupFile(1, "/dev/stdout"), dupFile(2, "/dev/stderr")
+	}
+	return stdout, stderr
+}
+
+func dupFile(fd int, name string) *os.File {
+	dup, err := unix.Dup(fd)
+	if err != nil {
+		panic(err)
+	}
+	unix.CloseOnExec(dup)
+	return os.NewFile(uintptr(dup), name)
+}
+
+// captureStdio redirects the descriptors 1 and 2 through pipes and writes
+// their lines as entries of lg, it returns a function restoring them.
+func (lg *Logger) captureStdio() func() {
+	if !lg.cfg.Capture.Enable {
+		return func() {}
+	}
+	outLv, errLv, err := lg.cfg.Capture.levels()
+	if err != nil {
+		panic(err)
+	}
+	origOut, origErr := stdio(lg.cfg)
+	restoreCrash := keepCrashOutput(origErr)
+	var wg sync.WaitGroup
+	redirect := func(fd int, lv zapcore.Level, stream string) *os.File {
+		r, w, err := os.Pipe()
+		if err != nil {
+			panic(err)
+		}
+		if err := unix.Dup2(int(w.Fd()), fd); err != nil {
+			panic(err)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer r.Close()
+			captureLines(r, lg.Zap(), lv, stream)
+		}()
+		return w
+	}
+	wOut := redirect(1, outLv, "stdout")
+	wErr := redirect(2, errLv, "stderr")
+	return func() {
+		_ = unix.Dup2(int(origOut.Fd()), 1)
+		_ = unix.Dup2(int(origErr.Fd()), 2)
+		restoreCrash()
+		_ = wOut.Close()
+		_ = wErr.Close()
+		wg.Wait()
+	}
+}
@@ -52,9 +52,25 @@ type BufferConfig struct {
 
 type Config struct {
 	Level      string
+	Levels     map[string]string
 	AddCaller  bool
 	CallerSkip int
-	File       struct {
+	Caller     CallerConfig
+	// ECS configures the "ecs" encoding.
+	ECS ECSConfig
+	// GCP configures the "gcp" encoding.
+	GCP GCPConfig
+	// Layout configures the "layout" encoding.
+	Layout LayoutConfig
+	// Encoding selects the registered encodings of the sinks.
+	Encoding struct {
+		// File is "json" by default.
+		File string
+		// Console is "console" by default, "layout" writes the lines
+		// following the Layout section.
+		Console string
+	}
+	File struct {
 		Enable bool
 		FileConfig
 		Encoder *zapcore.EncoderConfig
@@ -63,6 +79,79 @@ type Config struct {
 		Enable  bool
 		Encoder *zapcore.EncoderConfig
 	}
+	// Static adds the fields of the process to every entry.
+	Static struct {
+		Enable bool
+		StaticConfig
+	}
+	// Kubernetes adds the metadata of the pod and the container to every entry.
+	Kubernetes struct {
+		Enable bool
+		KubernetesConfig
+	}
+	// Stacktrace replaces the stacks captured by zap at the panic level.
+	Stacktrace struct {
+		Enable bool
+		StacktraceConfig
+	}
+	Gelf struct {
+		Enable bool
+		GelfConfig
+		Encoder *zapcore.EncoderConfig
+	}
+	Journald struct {
+		Enable bool
+		JournaldConfig
+	}
+	FlightRecorder struct {
+		Enable bool
+		FlightRecorderConfig
+	}
+	Redact struct {
+		Enable bool
+		RedactConfig
+	}
+	// GrpcLog installs the logger as the grpclog logger of grpc-go when the
+	// writer is built by the "zap" writer builder.
+	GrpcLog struct {
+		Enable bool
+		GrpcLogConfig
+	}
+	// Capture redirects the descriptors 1 and 2 of the process to the logger,
+	// the console sink and the crash output keep the original descriptors.
+	Capture struct {
+		Enable bool
+		CaptureConfig
+	}
+	Globals struct {
+		// RedirectStdLog redirects the standard library log to the logger.
+		RedirectStdLog bool
+		// ReplaceGlobals replaces zap.L and zap.S with the logger.
+		ReplaceGlobals bool
+	}
+	// Keys moves the fields colliding with the keys of the entries, flattens
+	// the nested objects and normalises the case of the keys.
+	Keys struct {
+		Enable bool
+		KeysConfig
+	}
+	// Errors expands the error fields into objects.
+	Errors struct {
+		Enable bool
+		ErrorsConfig
+	}
+	// Admin serves the handler of NewAdminHandler on the governor.
+	Admin struct {
+		Enable bool
+		// Path is "/zap/loggers" by default.
+		Path string
+	}
+	Signal struct {
+		// Level steps the level down on SIGUSR1 and up on SIGUSR2.
+		Level bool
+		// Reopen reopens the file sink on SIGHUP.
+		Reopen bool
+	}
 }
 
 // DebugEncodeLevel ...
@@ -121,6 +210,24 @@ func (config *Config) Build() *Logger {
 			}
 		}
 	}
+	if config.Gelf.Enable {
+		if config.Gelf.Encoder == nil {
+			config.Gelf.Encoder = &zapcore.EncoderConfig{
+				NameKey:        "logger",
+				CallerKey:      "caller",
+				LineEnding:     zapcore.DefaultLineEnding,
+				EncodeDuration: zapcore.SecondsDurationEncoder,
+				EncodeCaller:   zapcore.ShortCallerEncoder,
+			}
+		}
+	}
+	if config.Caller.FunctionKey != "" {
+		for _, encoder := range []*zapcore.EncoderConfig{config.File.Encoder, config.Console.Encoder, config.Gelf.Encoder} {
+			if encoder != nil && encoder.FunctionKey == "" {
+				encoder.FunctionKey = config.Caller.FunctionKey
+			}
+		}
+	}
 
 	config.Level = config2.Get(config2.KeyLoggerLevel).String("debug")
 	return NewLogger(config)
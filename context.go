@@ -0,0 +1,81 @@
+// Copyright 2022 The imkuqin-zw Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zap
+
+import (
+	"context"
+
+	"github.com/imkuqin-zw/yggdrasil/pkg/logger"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	traceIDKey = "trace_id"
+
+	spanIDKey = "span_id"
+)
+
+type (
+	forceDebugKey struct{}
+	fieldsKey     struct{}
+)
+
+// WithForceDebug marks ctx so the entries written with it bypass the level
+// of the logger.
+func WithForceDebug(ctx context.Context) context.Context {
+	return context.WithValue(ctx, forceDebugKey{}, true)
+}
+
+// IsForceDebug reports whether ctx was marked by WithForceDebug.
+func IsForceDebug(ctx context.Context) bool {
+	force, _ := ctx.Value(forceDebugKey{}).(bool)
+	return force
+}
+
+// WithFields returns a copy of ctx carrying the key-value pairs in addition
+// to the pairs already carried by ctx.
+func WithFields(ctx context.Context, kvs ...interface{}) context.Context {
+	if len(kvs) == 0 {
+		return ctx
+	}
+	parent := FieldsFromContext(ctx)
+	fields := make([]interface{}, 0, len(parent)+len(kvs))
+	fields = append(fields, parent...)
+	fields = append(fields, kvs...)
+	return context.WithValue(ctx, fieldsKey{}, fields)
+}
+
+// FieldsFromContext returns the key-value pairs carried by ctx.
+func FieldsFromContext(ctx context.Context) []interface{} {
+	fields, _ := ctx.Value(fieldsKey{}).([]interface{})
+	return fields
+}
+
+// WriteContext writes like Write and adds the trace and span ids of the span
+// and the fields carried by ctx before kvs. The entries of a context marked
+// by WithForceDebug are written whatever the level of the logger.
+func (lg *Logger) WriteContext(ctx context.Context, lv logger.Level, msg string, kvs ...interface{}) {
+	if fields := FieldsFromContext(ctx); len(fields) > 0 {
+		kvs = append(fields[:len(fields):len(fields)], kvs...)
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		kvs = append([]interface{}{traceIDKey, sc.TraceID().String(), spanIDKey, sc.SpanID().String()}, kvs...)
+	}
+	if IsForceDebug(ctx) {
+		lg.write(lg.forced, lv, msg, kvs)
+		return
+	}
+	lg.write(lg.sugar, lv, msg, kvs)
+}
@@ -0,0 +1,77 @@
+// Copyright 2022 The imkuqin-zw Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zap
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/imkuqin-zw/yggdrasil/pkg/interceptor"
+	"github.com/imkuqin-zw/yggdrasil/pkg/logger"
+	"github.com/imkuqin-zw/yggdrasil/pkg/metadata"
+	"github.com/imkuqin-zw/yggdrasil/pkg/remote/peer"
+	"go.uber.org/zap/zapcore"
+)
+
+func Test_WriteContextForceDebug(t *testing.T) {
+	lg, lines := newTestLogger(t, &Config{})
+	lg.SetLevel(zapcore.InfoLevel)
+
+	lg.WriteContext(context.Background(), logger.LvDebug, "dropped")
+	unary := newUnaryServerInterceptor()
+	info := &interceptor.UnaryServerInfo{FullMethod: "/test/Method"}
+	ctx := metadata.WithInContext(context.Background(), metadata.New(map[string]string{"X-Debug-Log": "1"}))
+	_, _ = unary(ctx, nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		lg.WriteContext(ctx, logger.LvDebug, "forced")
+		return nil, nil
+	})
+	got := lines()
+	if len(got) != 1 || decodeLine(t, got[0])["msg"] != "forced" {
+		t.Fatalf("unexpected entries: %q", got)
+	}
+}
+
+func Test_WriteContextFields(t *testing.T) {
+	lg, lines := newTestLogger(t, &Config{})
+	lg.SetLevel(zapcore.InfoLevel)
+
+	unary := newUnaryServerInterceptor()
+	info := &interceptor.UnaryServerInfo{FullMethod: "/test/Method"}
+	ctx := metadata.WithInContext(context.Background(), metadata.New(map[string]string{"x-request-id": "abc"}))
+	ctx = peer.PeerWithContext(ctx, &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 8080}})
+	_, _ = unary(ctx, nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		ctx = WithFields(ctx, "user_id", 42)
+		lg.WriteContext(ctx, logger.LvInfo, "handled", "status", "ok")
+		return nil, nil
+	})
+	entries := lines()
+	if len(entries) != 1 {
+		t.Fatalf("unexpected entries: %q", entries)
+	}
+	got := decodeLine(t, entries[0])
+	want := map[string]interface{}{
+		"request_id": "abc",
+		"method":     "/test/Method",
+		"peer":       "127.0.0.1:8080",
+		"user_id":    float64(42),
+		"status":     "ok",
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("field %s: got %v, want %v", k, got[k], v)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright 2022 The imkuqin-zw Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zap
+
+import (
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// anyLevel is the enabler of sinks accepting every entry.
+var anyLevel = zap.LevelEnablerFunc(func(zapcore.Level) bool { return true })
+
+// sinkCore fans entries out to the sinks. The sinks only route entries, the
+// level of the logger is applied on top of it, and unlike zapcore.NewTee its
+// Write honours the enabler of every sink so it can be written directly.
+type sinkCore []zapcore.Core
+
+func (sc sinkCore) Enabled(lvl zapcore.Level) bool {
+	for i := range sc {
+		if sc[i].Enabled(lvl) {
+			return true
+		}
+	}
+	return false
+}
+
+func (sc sinkCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := make(sinkCore, len(sc))
+	for i := range sc {
+		clone[i] = sc[i].With(fields)
+	}
+	return clone
+}
+
+func (sc sinkCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	for i := range sc {
+		ce = sc[i].Check(ent, ce)
+	}
+	return ce
+}
+
+func (sc sinkCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	var err error
+	for i := range sc {
+		if sc[i].Enabled(ent.Level) {
+			err = multierr.Append(err, sc[i].Write(ent, fields))
+		}
+	}
+	return err
+}
+
+func (sc sinkCore) Sync() error {
+	var err error
+	for i := range sc {
+		err = multierr.Append(err, sc[i].Sync())
+	}
+	return err
+}
+
+// levelCore gates the sinks with the levels of the logger.
+type levelCore struct {
+	zapcore.Core
+	lvs *levels
+}
+
+func newLevelCore(core zapcore.Core, lvs *levels) zapcore.Core {
+	return &levelCore{Core: core, lvs: lvs}
+}
+
+func (c *levelCore) Enabled(lvl zapcore.Level) bool {
+	return c.lvs.Enabled(lvl)
+}
+
+func (c *levelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelCore{Core: c.Core.With(fields), lvs: c.lvs}
+}
+
+func (c *levelCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.lvs.EnabledFor(ent.LoggerName, ent.Level) {
+		return c.Core.Check(ent, ce)
+	}
+	return ce
+}
+
+// fieldsCore rewrites the fields of the logger and of the entries before they
+// reach the sinks.
+type fieldsCore struct {
+	zapcore.Core
+	rewrite func([]zapcore.Field) []zapcore.Field
+}
+
+func newFieldsCore(core zapcore.Core, rewrite func([]zapcore.Field) []zapcore.Field) zapcore.Core {
+	return &fieldsCore{Core: core, rewrite: rewrite}
+}
+
+func (c *fieldsCore) With(fields []zapcore.Field) zapcore.Core {
+	return &fieldsCore{Core: c.Core.With(c.rewrite(fields)), rewrite: c.rewrite}
+}
+
+func (c *fieldsCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *fieldsCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	return c.Core.Write(ent, c.rewrite(fields))
+}
@@ -0,0 +1,199 @@
+// Copyright 2022 The imkuqin-zw Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zap
+
+import (
+	"fmt"
+
+	config2 "github.com/imkuqin-zw/yggdrasil/pkg/config"
+	"go.uber.org/zap"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	ecsVersion = "1.6.0"
+
+	defaultECSErrorKey = "error"
+)
+
+func init() {
+	RegisterEncoder("ecs", func(cfg *Config, encCfg zapcore.EncoderConfig) (zapcore.Encoder, error) {
+		return NewECSEncoder(encCfg, &cfg.ECS), nil
+	})
+}
+
+type ECSConfig struct {
+	// Labels moves the user fields under "labels", they are kept at the top
+	// level otherwise.
+	Labels bool
+	// ServiceName defaults to the name of the yggdrasil application.
+	ServiceName string
+	// ServiceVersion defaults to the version of the yggdrasil application.
+	ServiceVersion string
+	// TraceKey is the key of the field moved to trace.id, "trace_id" by default.
+	TraceKey string
+	// SpanKey is the key of the field moved to span.id, "span_id" by default.
+	SpanKey string
+	// ErrorKey is the key of the error field moved to error.*, "error" by default.
+	ErrorKey string
+}
+
+// ecsEncoder writes the ECS fields of the entry in a head and the user fields
+// in a body, the body is merged into the head at the top level or under
+// "labels".
+type ecsEncoder struct {
+	*prefixEncoder
+	head       zapcore.Encoder
+	config     *ECSConfig
+	lineEnding string
+}
+
+// NewECSEncoder returns an encoder writing the entries with the field names
+// of the Elastic Common Schema.
+func NewECSEncoder(encCfg zapcore.EncoderConfig, config *ECSConfig) zapcore.Encoder {
+	if config.ServiceName == "" {
+		config.ServiceName = config2.Get(config2.KeyAppName).String("")
+	}
+	if config.ServiceVersion == "" {
+		config.ServiceVersion = config2.Get(config2.KeyAppVersion).String("")
+	}
+	if config.TraceKey == "" {
+		config.TraceKey = traceIDKey
+	}
+	if config.SpanKey == "" {
+		config.SpanKey = spanIDKey
+	}
+	if config.ErrorKey == "" {
+		config.ErrorKey = defaultECSErrorKey
+	}
+	lineEnding := encCfg.LineEnding
+	if lineEnding == "" {
+		lineEnding = zapcore.DefaultLineEnding
+	}
+	body := encCfg
+	body.TimeKey, body.LevelKey, body.NameKey, body.CallerKey = "", "", "", ""
+	body.FunctionKey, body.MessageKey, body.StacktraceKey = "", "", ""
+	body.SkipLineEnding = true
+	head := body
+	head.TimeKey = "@timestamp"
+	head.LevelKey = "log.level"
+	head.NameKey = "log.logger"
+	head.MessageKey = "message"
+	head.EncodeTime = zapcore.TimeEncoderOfLayout("2006-01-02T15:04:05.000Z07:00")
+	head.EncodeLevel = zapcore.LowercaseLevelEncoder
+	head.EncodeName = zapcore.FullNameEncoder
+	enc := &ecsEncoder{
+		head:       zapcore.NewJSONEncoder(head),
+		config:     config,
+		lineEnding: lineEnding,
+	}
+	enc.prefixEncoder = newPrefixEncoder(zapcore.NewJSONEncoder(body), enc.ReservedKeys())
+	return enc
+}
+
+// ReservedKeys returns the ECS keys written at the top level with the user
+// fields, none when the user fields are moved under "labels".
+func (enc *ecsEncoder) ReservedKeys() []string {
+	if enc.config.Labels {
+		return []string{}
+	}
+	return []string{"@timestamp", "log.level", "log.logger", "message", "ecs.version",
+		"log.origin.file.name", "log.origin.file.line", "log.origin.function",
+		"service.name", "service.version", "trace.id", "span.id",
+		"error.message", "error.type", "error.stack_trace"}
+}
+
+// isErrorField reports whether f holds an error, either as is or expanded by
+// the Errors config.
+func isErrorField(f zapcore.Field) bool {
+	if f.Type == zapcore.ObjectMarshalerType {
+		_, ok := f.Interface.(errorObject)
+		return ok
+	}
+	return f.Type == zapcore.ErrorType
+}
+
+func fieldError(f zapcore.Field) error {
+	if obj, ok := f.Interface.(errorObject); ok {
+		return obj.err
+	}
+	return f.Interface.(error)
+}
+
+func (enc *ecsEncoder) Clone() zapcore.Encoder {
+	return &ecsEncoder{prefixEncoder: enc.prefixEncoder.clone(), head: enc.head, config: enc.config, lineEnding: enc.lineEnding}
+}
+
+func (enc *ecsEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	ent.Time = ent.Time.UTC()
+	head := make([]zapcore.Field, 0, 8)
+	head = append(head, zap.String("ecs.version", ecsVersion))
+	if ent.Caller.Defined {
+		head = append(head, zap.String("log.origin.file.name", ent.Caller.File), zap.Int("log.origin.file.line", ent.Caller.Line))
+		if ent.Caller.Function != "" {
+			head = append(head, zap.String("log.origin.function", ent.Caller.Function))
+		}
+	}
+	if enc.config.ServiceName != "" {
+		head = append(head, zap.String("service.name", enc.config.ServiceName))
+	}
+	if enc.config.ServiceVersion != "" {
+		head = append(head, zap.String("service.version", enc.config.ServiceVersion))
+	}
+	stack := ent.Stack
+	user := make([]zapcore.Field, 0, len(fields))
+	for _, f := range fields {
+		switch {
+		case f.Key == enc.config.TraceKey:
+			f.Key = "trace.id"
+			head = append(head, f)
+		case f.Key == enc.config.SpanKey:
+			f.Key = "span.id"
+			head = append(head, f)
+		case f.Key == enc.config.ErrorKey && isErrorField(f):
+			err := fieldError(f)
+			msg, ok := errorMessage(err)
+			head = append(head, zap.String("error.message", msg), zap.String("error.type", fmt.Sprintf("%T", err)))
+			if verbose := fmt.Sprintf("%+v", err); ok && stack == "" && verbose != msg {
+				stack = verbose
+			}
+		default:
+			user = append(user, f)
+		}
+	}
+	if stack != "" {
+		head = append(head, zap.String("error.stack_trace", stack))
+	}
+	ent.Stack = ""
+	ent.Caller = zapcore.EntryCaller{}
+
+	headBuf, err := enc.head.EncodeEntry(ent, head)
+	if err != nil {
+		return nil, err
+	}
+	defer headBuf.Free()
+	bodyBuf, err := enc.Encoder.EncodeEntry(zapcore.Entry{}, enc.fields(user))
+	if err != nil {
+		return nil, err
+	}
+	defer bodyBuf.Free()
+
+	nest := ""
+	if enc.config.Labels {
+		nest = "labels"
+	}
+	return mergeJSON(headBuf, bodyBuf, nest, enc.lineEnding), nil
+}
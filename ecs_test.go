@@ -0,0 +1,138 @@
+// Copyright 2022 The imkuqin-zw Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zap
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func Test_ECSEncoder(t *testing.T) {
+	ent := zapcore.Entry{
+		Level:      zapcore.ErrorLevel,
+		Time:       time.Date(2022, 1, 2, 3, 4, 5, 6e6, time.UTC),
+		LoggerName: "app",
+		Message:    "failed",
+		Caller:     zapcore.NewEntryCaller(0, "/src/main.go", 42, true),
+	}
+	fields := []zapcore.Field{zap.String("trace_id", "t1"), zap.Error(errors.New("boom")), zap.Int("k", 1)}
+
+	enc := NewECSEncoder(zapcore.EncoderConfig{}, &ECSConfig{ServiceName: "svc", ServiceVersion: "1.0.0", Labels: true})
+	enc.AddString("ctx", "v")
+	buf, err := enc.EncodeEntry(ent, fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("%v: %s", err, buf.Bytes())
+	}
+	want := map[string]interface{}{
+		"@timestamp":           "2022-01-02T03:04:05.006Z",
+		"log.level":            "error",
+		"log.logger":           "app",
+		"message":              "failed",
+		"ecs.version":          ecsVersion,
+		"log.origin.file.name": "/src/main.go",
+		"log.origin.file.line": float64(42),
+		"service.name":         "svc",
+		"service.version":      "1.0.0",
+		"trace.id":             "t1",
+		"error.message":        "boom",
+		"error.type":           "*errors.errorString",
+		"labels":               map[string]interface{}{"ctx": "v", "k": float64(1)},
+	}
+	for k, v := range want {
+		if b1, b2 := mustJSON(t, got[k]), mustJSON(t, v); b1 != b2 {
+			t.Errorf("%s: got %s, want %s", k, b1, b2)
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("unexpected keys: %s", buf.Bytes())
+	}
+
+	enc = NewECSEncoder(zapcore.EncoderConfig{}, &ECSConfig{})
+	buf, err = enc.EncodeEntry(ent, []zapcore.Field{zap.Int("k", 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = nil
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil || got["k"] != float64(1) {
+		t.Fatalf("top-level field missing: %v %s", err, buf.Bytes())
+	}
+
+	var nilErr *ptrError
+	buf, err = enc.EncodeEntry(ent, []zapcore.Field{zap.Error(nilErr)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = nil
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil || got["error.message"] != "<nil>" {
+		t.Fatalf("unexpected nil error: %v %s", err, buf.Bytes())
+	}
+}
+
+func mustJSON(t *testing.T, v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func Test_ECSExpandedError(t *testing.T) {
+	cfg := &Config{}
+	cfg.Encoding.File = "ecs"
+	cfg.Errors.Enable = true
+	cfg.Errors.Chain = true
+	lg, lines := newTestLogger(t, cfg)
+
+	lg.Zap().Error("failed", zap.Error(fmt.Errorf("load: %w", errors.New("boom"))))
+
+	entries := lines()
+	if len(entries) != 1 {
+		t.Fatalf("unexpected entries: %q", entries)
+	}
+	got := decodeLine(t, entries[0])
+	if got["error.message"] != "load: boom" || got["error.type"] != "*fmt.wrapError" {
+		t.Errorf("expanded error not mapped: %v", got)
+	}
+	if _, ok := got["error"]; ok {
+		t.Errorf("expanded error left as an object: %v", got)
+	}
+}
+
+func Test_ECSCollision(t *testing.T) {
+	cfg := &Config{}
+	cfg.Encoding.File = "ecs"
+	lg, lines := newTestLogger(t, cfg)
+	lg.Zap().With(zap.String("message", "c")).Info("hello", zap.String("log.level", "l"))
+
+	line := lines()[0]
+	if strings.Count(line, `"message"`) != 1 || strings.Count(line, `"log.level"`) != 1 {
+		t.Fatalf("duplicate keys: %s", line)
+	}
+	got := decodeLine(t, line)
+	if got["message"] != "hello" || got["fields.message"] != "c" || got["fields.log.level"] != "l" {
+		t.Errorf("colliding fields not prefixed: %s", line)
+	}
+}
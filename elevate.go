@@ -0,0 +1,89 @@
+// Copyright 2022 The imkuqin-zw Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zap
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// elevation remembers how to restore the original level of a logger while
+// its level is temporarily changed.
+type elevation struct {
+	restore func()
+	expire  time.Time
+	timer   *time.Timer
+}
+
+type elevations struct {
+	mu    sync.Mutex
+	items map[string]*elevation
+}
+
+// ElevateFor changes the level of the logger and restores the original one
+// after d. Concurrent elevations keep the original level and the latest
+// expiry wins.
+func (lg *Logger) ElevateFor(lv zapcore.Level, d time.Duration) {
+	elevs := lg.levels.elevations
+	elevs.mu.Lock()
+	prev := lg.Level()
+	e, ok := elevs.items[lg.name]
+	if ok {
+		lg.setLevel(lv)
+	} else {
+		e = &elevation{restore: lg.setLevel(lv)}
+		elevs.items[lg.name] = e
+	}
+	if expire := time.Now().Add(d); expire.After(e.expire) {
+		e.expire = expire
+		if e.timer != nil {
+			e.timer.Stop()
+		}
+		e.timer = time.AfterFunc(d, func() { lg.revert(e) })
+	}
+	expire := e.expire
+	elevs.mu.Unlock()
+	// The transitions are logged whatever the level of the logger.
+	lg.forced.Infow("log level elevated", "from", prev, "to", lv, "until", expire)
+}
+
+func (lg *Logger) revert(e *elevation) {
+	elevs := lg.levels.elevations
+	elevs.mu.Lock()
+	// The elevation was extended or replaced by SetLevel in the meantime.
+	if elevs.items[lg.name] != e || time.Now().Before(e.expire) {
+		elevs.mu.Unlock()
+		return
+	}
+	delete(elevs.items, lg.name)
+	prev := lg.Level()
+	e.restore()
+	elevs.mu.Unlock()
+	lg.forced.Infow("log level restored", "from", prev, "to", lg.Level())
+}
+
+// cancelElevation forgets the pending elevation of the logger, the current
+// level is kept.
+func (lg *Logger) cancelElevation() {
+	elevs := lg.levels.elevations
+	elevs.mu.Lock()
+	defer elevs.mu.Unlock()
+	if e, ok := elevs.items[lg.name]; ok {
+		e.timer.Stop()
+		delete(elevs.items, lg.name)
+	}
+}
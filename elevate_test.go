@@ -0,0 +1,53 @@
+// Copyright 2022 The imkuqin-zw Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zap
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func Test_ElevateFor(t *testing.T) {
+	lg, lines := newTestLogger(t, &Config{})
+	defer lg.Close()
+	lg.SetLevel(zapcore.ErrorLevel)
+
+	lg.ElevateFor(zapcore.DebugLevel, 300*time.Millisecond)
+	lg.ElevateFor(zapcore.WarnLevel, 50*time.Millisecond)
+	if lg.Level() != zapcore.WarnLevel {
+		t.Fatalf("level not elevated: %v", lg.Level())
+	}
+	time.Sleep(150 * time.Millisecond)
+	if lg.Level() != zapcore.WarnLevel {
+		t.Fatalf("earlier expiry reverted the level: %v", lg.Level())
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for lg.Level() != zapcore.ErrorLevel {
+		if time.Now().After(deadline) {
+			t.Fatal("original level not restored")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	counts := map[string]int{}
+	for _, line := range lines() {
+		counts[decodeLine(t, line)["msg"].(string)]++
+	}
+	if counts["log level elevated"] != 2 || counts["log level restored"] != 1 {
+		t.Errorf("transitions not logged above their level: %v", counts)
+	}
+}
@@ -0,0 +1,210 @@
+// Copyright 2022 The imkuqin-zw Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zap
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+var mergePool = buffer.NewPool()
+
+// EncoderBuilder builds the encoder of a sink from the config of the logger
+// and the encoder config of the sink.
+type EncoderBuilder func(cfg *Config, encCfg zapcore.EncoderConfig) (zapcore.Encoder, error)
+
+var encoderBuilders = map[string]EncoderBuilder{
+	"json": func(_ *Config, encCfg zapcore.EncoderConfig) (zapcore.Encoder, error) {
+		return zapcore.NewJSONEncoder(encCfg), nil
+	},
+	"console": func(_ *Config, encCfg zapcore.EncoderConfig) (zapcore.Encoder, error) {
+		return zapcore.NewConsoleEncoder(encCfg), nil
+	},
+}
+
+// RegisterEncoder registers the builder of an encoding, it is not safe for
+// concurrent use and should be called in init.
+func RegisterEncoder(name string, builder EncoderBuilder) {
+	encoderBuilders[name] = builder
+}
+
+func newEncoder(cfg *Config, encoding, def string, encCfg zapcore.EncoderConfig) zapcore.Encoder {
+	if encoding == "" {
+		encoding = def
+	}
+	builder, ok := encoderBuilders[encoding]
+	if !ok {
+		panic(fmt.Errorf("unknown encoding %q", encoding))
+	}
+	encoder, err := builder(cfg, encCfg)
+	if err != nil {
+		panic(err)
+	}
+	return encoder
+}
+
+// ReservedKeysEncoder is implemented by the encoders writing top-level keys
+// other than the ones of their EncoderConfig, the Keys config moves the
+// fields colliding with them.
+type ReservedKeysEncoder interface {
+	zapcore.Encoder
+	ReservedKeys() []string
+}
+
+// addEncoderKeys adds the top-level keys written by enc to reserved.
+func addEncoderKeys(reserved map[string]bool, enc zapcore.Encoder, encCfg *zapcore.EncoderConfig) {
+	keys := []string{encCfg.TimeKey, encCfg.LevelKey, encCfg.NameKey, encCfg.CallerKey,
+		encCfg.FunctionKey, encCfg.MessageKey, encCfg.StacktraceKey}
+	if r, ok := enc.(ReservedKeysEncoder); ok {
+		keys = r.ReservedKeys()
+	}
+	for _, key := range keys {
+		if key != "" {
+			reserved[key] = true
+		}
+	}
+}
+
+// prefixEncoder prefixes the top-level keys colliding with the reserved ones,
+// the encoders merging their own keys with the fields would write duplicate
+// keys otherwise.
+type prefixEncoder struct {
+	zapcore.Encoder
+	reserved map[string]bool
+	// inner is set once a namespace is opened, its keys never collide.
+	inner bool
+}
+
+func newPrefixEncoder(enc zapcore.Encoder, reserved []string) *prefixEncoder {
+	keys := make(map[string]bool, len(reserved))
+	for _, key := range reserved {
+		keys[key] = true
+	}
+	return &prefixEncoder{Encoder: enc, reserved: keys}
+}
+
+func (enc *prefixEncoder) clone() *prefixEncoder {
+	return &prefixEncoder{Encoder: enc.Encoder.Clone(), reserved: enc.reserved, inner: enc.inner}
+}
+
+func (enc *prefixEncoder) key(key string) string {
+	if !enc.inner && enc.reserved[key] {
+		return defaultKeysPrefix + key
+	}
+	return key
+}
+
+// fields prefixes the colliding fields of an entry.
+func (enc *prefixEncoder) fields(fields []zapcore.Field) []zapcore.Field {
+	if enc.inner {
+		return fields
+	}
+	var out []zapcore.Field
+	for i, f := range fields {
+		if f.Type == zapcore.NamespaceType {
+			break
+		}
+		if !enc.reserved[f.Key] {
+			continue
+		}
+		if out == nil {
+			out = make([]zapcore.Field, len(fields))
+			copy(out, fields)
+		}
+		out[i].Key = defaultKeysPrefix + f.Key
+	}
+	if out == nil {
+		return fields
+	}
+	return out
+}
+
+func (enc *prefixEncoder) AddArray(key string, v zapcore.ArrayMarshaler) error {
+	return enc.Encoder.AddArray(enc.key(key), v)
+}
+
+func (enc *prefixEncoder) AddObject(key string, v zapcore.ObjectMarshaler) error {
+	return enc.Encoder.AddObject(enc.key(key), v)
+}
+
+func (enc *prefixEncoder) AddBinary(key string, v []byte) { enc.Encoder.AddBinary(enc.key(key), v) }
+
+func (enc *prefixEncoder) AddByteString(key string, v []byte) {
+	enc.Encoder.AddByteString(enc.key(key), v)
+}
+
+func (enc *prefixEncoder) AddBool(key string, v bool) { enc.Encoder.AddBool(enc.key(key), v) }
+
+func (enc *prefixEncoder) AddComplex128(key string, v complex128) {
+	enc.Encoder.AddComplex128(enc.key(key), v)
+}
+
+func (enc *prefixEncoder) AddComplex64(key string, v complex64) {
+	enc.Encoder.AddComplex64(enc.key(key), v)
+}
+
+func (enc *prefixEncoder) AddDuration(key string, v time.Duration) {
+	enc.Encoder.AddDuration(enc.key(key), v)
+}
+
+func (enc *prefixEncoder) AddFloat64(key string, v float64) { enc.Encoder.AddFloat64(enc.key(key), v) }
+func (enc *prefixEncoder) AddFloat32(key string, v float32) { enc.Encoder.AddFloat32(enc.key(key), v) }
+func (enc *prefixEncoder) AddInt(key string, v int)         { enc.Encoder.AddInt(enc.key(key), v) }
+func (enc *prefixEncoder) AddInt64(key string, v int64)     { enc.Encoder.AddInt64(enc.key(key), v) }
+func (enc *prefixEncoder) AddInt32(key string, v int32)     { enc.Encoder.AddInt32(enc.key(key), v) }
+func (enc *prefixEncoder) AddInt16(key string, v int16)     { enc.Encoder.AddInt16(enc.key(key), v) }
+func (enc *prefixEncoder) AddInt8(key string, v int8)       { enc.Encoder.AddInt8(enc.key(key), v) }
+func (enc *prefixEncoder) AddString(key, v string)          { enc.Encoder.AddString(enc.key(key), v) }
+func (enc *prefixEncoder) AddTime(key string, v time.Time)  { enc.Encoder.AddTime(enc.key(key), v) }
+func (enc *prefixEncoder) AddUint(key string, v uint)       { enc.Encoder.AddUint(enc.key(key), v) }
+func (enc *prefixEncoder) AddUint64(key string, v uint64)   { enc.Encoder.AddUint64(enc.key(key), v) }
+func (enc *prefixEncoder) AddUint32(key string, v uint32)   { enc.Encoder.AddUint32(enc.key(key), v) }
+func (enc *prefixEncoder) AddUint16(key string, v uint16)   { enc.Encoder.AddUint16(enc.key(key), v) }
+func (enc *prefixEncoder) AddUint8(key string, v uint8)     { enc.Encoder.AddUint8(enc.key(key), v) }
+func (enc *prefixEncoder) AddUintptr(key string, v uintptr) { enc.Encoder.AddUintptr(enc.key(key), v) }
+
+func (enc *prefixEncoder) AddReflected(key string, v interface{}) error {
+	return enc.Encoder.AddReflected(enc.key(key), v)
+}
+
+func (enc *prefixEncoder) OpenNamespace(key string) {
+	enc.Encoder.OpenNamespace(enc.key(key))
+	enc.inner = true
+}
+
+// mergeJSON merges the fields of the body object into the head object, at
+// the top level or under nest.
+func mergeJSON(head, body *buffer.Buffer, nest, lineEnding string) *buffer.Buffer {
+	out := mergePool.Get()
+	out.Write(bytes.TrimSuffix(head.Bytes(), []byte("}")))
+	if b := body.Bytes(); len(b) > 2 {
+		if nest != "" {
+			out.AppendString(`,"`)
+			out.AppendString(nest)
+			out.AppendString(`":`)
+			out.Write(b)
+		} else {
+			out.AppendByte(',')
+			out.Write(b[1 : len(b)-1])
+		}
+	}
+	out.AppendByte('}')
+	out.AppendString(lineEnding)
+	return out
+}
@@ -0,0 +1,204 @@
+// Copyright 2022 The imkuqin-zw Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zap
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"runtime"
+
+	"github.com/imkuqin-zw/yggdrasil/pkg/status"
+	pkgerrors "github.com/pkg/errors"
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/genproto/googleapis/rpc/code"
+	grpcstatus "google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+type ErrorsConfig struct {
+	// Chain adds the messages of the errors.Unwrap chain.
+	Chain bool
+	// Multi expands the members of the multi-errors.
+	Multi bool
+	// Status adds the code and the details of the status errors.
+	Status bool
+	// Stack adds the stack of the github.com/pkg/errors errors as frames.
+	Stack bool
+}
+
+// expandErrors replaces the error fields with objects holding the message
+// and the configured expansions of the error.
+func (config *ErrorsConfig) expandErrors(fields []zapcore.Field) []zapcore.Field {
+	var expanded []zapcore.Field
+	for i, f := range fields {
+		if f.Type != zapcore.ErrorType {
+			continue
+		}
+		if expanded == nil {
+			expanded = make([]zapcore.Field, len(fields))
+			copy(expanded, fields)
+		}
+		expanded[i] = zap.Object(f.Key, errorObject{err: f.Interface.(error), config: config})
+	}
+	if expanded == nil {
+		return fields
+	}
+	return expanded
+}
+
+type errorObject struct {
+	err    error
+	config *ErrorsConfig
+}
+
+func (e errorObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	msg, ok := errorMessage(e.err)
+	enc.AddString("msg", msg)
+	if !ok {
+		return nil
+	}
+	if e.config.Chain {
+		var chain []string
+		for err := errors.Unwrap(e.err); err != nil; err = errors.Unwrap(err) {
+			msg, ok := errorMessage(err)
+			chain = append(chain, msg)
+			if !ok {
+				break
+			}
+		}
+		if len(chain) > 0 {
+			if err := enc.AddArray("chain", zapcore.ArrayMarshalerFunc(func(enc zapcore.ArrayEncoder) error {
+				for _, msg := range chain {
+					enc.AppendString(msg)
+				}
+				return nil
+			})); err != nil {
+				return err
+			}
+		}
+	}
+	if e.config.Multi {
+		if members := multiErrors(e.err); len(members) > 0 {
+			if err := enc.AddArray("errors", zapcore.ArrayMarshalerFunc(func(enc zapcore.ArrayEncoder) error {
+				for _, member := range members {
+					if err := enc.AppendObject(errorObject{err: member, config: e.config}); err != nil {
+						return err
+					}
+				}
+				return nil
+			})); err != nil {
+				return err
+			}
+		}
+	}
+	if e.config.Status {
+		if err := addStatus(enc, e.err); err != nil {
+			return err
+		}
+	}
+	if e.config.Stack {
+		if frames := pkgErrorsStack(e.err); len(frames) > 0 {
+			return enc.AddArray("stack", frames)
+		}
+	}
+	return nil
+}
+
+// errorMessage returns the message of err, or "<nil>" and false when err is
+// a nil pointer whose Error method panics, as zap.Error does.
+func errorMessage(err error) (msg string, ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			if v := reflect.ValueOf(err); v.Kind() == reflect.Ptr && v.IsNil() {
+				msg, ok = "<nil>", false
+				return
+			}
+			panic(r)
+		}
+	}()
+	return err.Error(), true
+}
+
+// multiErrors returns the members of the first multi-error of the chain.
+func multiErrors(err error) []error {
+	for ; err != nil; err = errors.Unwrap(err) {
+		if errs := multierr.Errors(err); len(errs) > 1 {
+			return errs
+		}
+		if multi, ok := err.(interface{ Unwrap() []error }); ok {
+			return multi.Unwrap()
+		}
+	}
+	return nil
+}
+
+func addStatus(enc zapcore.ObjectEncoder, err error) error {
+	var (
+		stCode  int32
+		details []*anypb.Any
+	)
+	if st, ok := status.CoverError(err); ok {
+		stCode, details = st.Code(), st.Status().GetDetails()
+	} else if st, ok := grpcstatus.FromError(err); ok {
+		stCode, details = int32(st.Code()), st.Proto().GetDetails()
+	} else {
+		return nil
+	}
+	enc.AddInt32("code", stCode)
+	enc.AddString("status", code.Code(stCode).String())
+	if len(details) == 0 {
+		return nil
+	}
+	raws := make([]json.RawMessage, 0, len(details))
+	for _, detail := range details {
+		raw, err := protojson.Marshal(detail)
+		if err != nil {
+			return err
+		}
+		raws = append(raws, raw)
+	}
+	return enc.AddReflected("details", raws)
+}
+
+// pkgErrorsStack returns the stack of the deepest github.com/pkg/errors error
+// of the chain, it is the closest to the origin of the error.
+func pkgErrorsStack(err error) stackFrames {
+	var trace pkgerrors.StackTrace
+	for ; err != nil; err = errors.Unwrap(err) {
+		if tracer, ok := err.(interface{ StackTrace() pkgerrors.StackTrace }); ok {
+			trace = tracer.StackTrace()
+		}
+	}
+	if len(trace) == 0 {
+		return nil
+	}
+	pcs := make([]uintptr, len(trace))
+	for i, frame := range trace {
+		pcs[i] = uintptr(frame)
+	}
+	frames := make(stackFrames, 0, len(pcs))
+	iter := runtime.CallersFrames(pcs)
+	for {
+		frame, more := iter.Next()
+		frames = append(frames, frame)
+		if !more {
+			return frames
+		}
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2022 The imkuqin-zw Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zap
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/imkuqin-zw/yggdrasil/pkg/status"
+	pkgerrors "github.com/pkg/errors"
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/genproto/googleapis/rpc/code"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+)
+
+func Test_ExpandErrors(t *testing.T) {
+	obs, logs := observer.New(zapcore.DebugLevel)
+	cfg := &ErrorsConfig{Chain: true, Multi: true, Status: true, Stack: true}
+	lg := zap.New(newFieldsCore(sinkCore{obs}, cfg.expandErrors))
+
+	err := fmt.Errorf("load: %w", multierr.Combine(
+		pkgerrors.New("a"),
+		status.Errorf(code.Code_NOT_FOUND, "b", &errdetails.ErrorInfo{Reason: "MISSING"}),
+	))
+	lg.Error("failed", zap.Error(err))
+
+	obj := logs.All()[0].ContextMap()["error"].(map[string]interface{})
+	if obj["msg"] != err.Error() {
+		t.Errorf("unexpected msg: %v", obj["msg"])
+	}
+	if chain, _ := obj["chain"].([]interface{}); len(chain) != 1 || !strings.HasPrefix(chain[0].(string), "a; ") {
+		t.Errorf("unexpected chain: %v", chain)
+	}
+	members := obj["errors"].([]interface{})
+	if len(members) != 2 {
+		t.Fatalf("unexpected members: %v", members)
+	}
+	stack := members[0].(map[string]interface{})["stack"].([]interface{})
+	if fn := stack[0].(map[string]interface{})["func"].(string); !strings.HasSuffix(fn, ".Test_ExpandErrors") {
+		t.Errorf("unexpected stack: %v", stack)
+	}
+	st := members[1].(map[string]interface{})
+	details, _ := json.Marshal(st["details"])
+	if st["code"] != int32(code.Code_NOT_FOUND) || st["status"] != "NOT_FOUND" || !strings.Contains(string(details), "MISSING") {
+		t.Errorf("unexpected status: %v %s", st, details)
+	}
+}
+
+type ptrError struct{ msg string }
+
+func (e *ptrError) Error() string { return e.msg }
+
+func Test_ExpandNilError(t *testing.T) {
+	cfg := &Config{}
+	cfg.Errors.Enable = true
+	cfg.Errors.Chain = true
+	cfg.Errors.Stack = true
+	lg, lines := newTestLogger(t, cfg)
+
+	var err *ptrError
+	lg.Zap().Error("failed", zap.Error(err))
+
+	entries := lines()
+	if len(entries) != 1 {
+		t.Fatalf("unexpected entries: %q", entries)
+	}
+	obj, _ := decodeLine(t, entries[0])["error"].(map[string]interface{})
+	if len(obj) != 1 || obj["msg"] != "<nil>" {
+		t.Fatalf("unexpected error: %v", obj)
+	}
+}
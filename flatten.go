@@ -0,0 +1,273 @@
+// Copyright 2022 The imkuqin-zw Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zap
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// rawJSON returns the encoded JSON held by a field. The fields of the
+// yggdrasil logger arrive as a json.RawMessage, which zap.Any turns into a
+// reflected field or, since json.RawMessage implements fmt.Stringer, into a
+// stringer field.
+func rawJSON(f zapcore.Field) (json.RawMessage, bool) {
+	switch f.Type {
+	case zapcore.ReflectType, zapcore.StringerType:
+		raw, ok := f.Interface.(json.RawMessage)
+		return raw, ok
+	}
+	return nil, false
+}
+
+// decodeJSON decodes b into maps, slices and the scalars of encoding/json,
+// the numbers are kept as json.Number.
+func decodeJSON(b []byte) (interface{}, error) {
+	var v interface{}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// genericField replaces the fields holding encoded JSON, maps or structs with
+// the fields of their decoded value, the objects become marshalers the
+// encoders can walk.
+func genericField(f zapcore.Field) zapcore.Field {
+	b, ok := rawJSON(f)
+	if !ok {
+		if f.Type != zapcore.ReflectType || !isObject(f.Interface) {
+			return f
+		}
+		var err error
+		if b, err = json.Marshal(f.Interface); err != nil {
+			return f
+		}
+	}
+	v, err := decodeJSON(b)
+	if err != nil {
+		return f
+	}
+	return jsonField(f.Key, v)
+}
+
+func isObject(value interface{}) bool {
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return false
+		}
+		v = v.Elem()
+	}
+	return v.Kind() == reflect.Map || v.Kind() == reflect.Struct
+}
+
+func jsonField(key string, v interface{}) zapcore.Field {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		return zap.Object(key, jsonObject(val))
+	case []interface{}:
+		return zap.Array(key, jsonArray(val))
+	case json.Number:
+		if i, err := val.Int64(); err == nil {
+			return zap.Int64(key, i)
+		}
+		f, _ := val.Float64()
+		return zap.Float64(key, f)
+	case string:
+		return zap.String(key, val)
+	case bool:
+		return zap.Bool(key, val)
+	}
+	return zap.Reflect(key, v)
+}
+
+// jsonObject is a decoded JSON object, the keys are written sorted.
+type jsonObject map[string]interface{}
+
+func (o jsonObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	keys := make([]string, 0, len(o))
+	for key := range o {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		jsonField(key, o[key]).AddTo(enc)
+	}
+	return nil
+}
+
+type jsonArray []interface{}
+
+func (a jsonArray) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for _, item := range a {
+		switch val := item.(type) {
+		case map[string]interface{}:
+			if err := enc.AppendObject(jsonObject(val)); err != nil {
+				return err
+			}
+		case []interface{}:
+			if err := enc.AppendArray(jsonArray(val)); err != nil {
+				return err
+			}
+		case json.Number:
+			if i, err := val.Int64(); err == nil {
+				enc.AppendInt64(i)
+			} else {
+				f, _ := val.Float64()
+				enc.AppendFloat64(f)
+			}
+		case string:
+			enc.AppendString(val)
+		case bool:
+			enc.AppendBool(val)
+		default:
+			if err := enc.AppendReflected(val); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// flattenEncoder collects the fields of an object with dotted keys, the
+// reflected maps and structs are flattened as well.
+type flattenEncoder struct {
+	prefix string
+	fields []zapcore.Field
+}
+
+func (enc *flattenEncoder) add(f zapcore.Field) {
+	f.Key = enc.prefix + f.Key
+	enc.fields = append(enc.fields, f)
+}
+
+func (enc *flattenEncoder) AddArray(key string, marshaler zapcore.ArrayMarshaler) error {
+	enc.add(zap.Array(key, marshaler))
+	return nil
+}
+
+func (enc *flattenEncoder) AddObject(key string, marshaler zapcore.ObjectMarshaler) error {
+	prefix := enc.prefix
+	enc.prefix = prefix + key + "."
+	err := marshaler.MarshalLogObject(enc)
+	enc.prefix = prefix
+	return err
+}
+
+func (enc *flattenEncoder) AddBinary(key string, value []byte) {
+	enc.add(zap.Binary(key, value))
+}
+
+func (enc *flattenEncoder) AddByteString(key string, value []byte) {
+	enc.add(zap.ByteString(key, value))
+}
+
+func (enc *flattenEncoder) AddBool(key string, value bool) {
+	enc.add(zap.Bool(key, value))
+}
+
+func (enc *flattenEncoder) AddComplex128(key string, value complex128) {
+	enc.add(zap.Complex128(key, value))
+}
+
+func (enc *flattenEncoder) AddComplex64(key string, value complex64) {
+	enc.add(zap.Complex64(key, value))
+}
+
+func (enc *flattenEncoder) AddDuration(key string, value time.Duration) {
+	enc.add(zap.Duration(key, value))
+}
+
+func (enc *flattenEncoder) AddFloat64(key string, value float64) {
+	enc.add(zap.Float64(key, value))
+}
+
+func (enc *flattenEncoder) AddFloat32(key string, value float32) {
+	enc.add(zap.Float32(key, value))
+}
+
+func (enc *flattenEncoder) AddInt(key string, value int) {
+	enc.add(zap.Int(key, value))
+}
+
+func (enc *flattenEncoder) AddInt64(key string, value int64) {
+	enc.add(zap.Int64(key, value))
+}
+
+func (enc *flattenEncoder) AddInt32(key string, value int32) {
+	enc.add(zap.Int32(key, value))
+}
+
+func (enc *flattenEncoder) AddInt16(key string, value int16) {
+	enc.add(zap.Int16(key, value))
+}
+
+func (enc *flattenEncoder) AddInt8(key string, value int8) {
+	enc.add(zap.Int8(key, value))
+}
+
+func (enc *flattenEncoder) AddString(key, value string) {
+	enc.add(zap.String(key, value))
+}
+
+func (enc *flattenEncoder) AddTime(key string, value time.Time) {
+	enc.add(zap.Time(key, value))
+}
+
+func (enc *flattenEncoder) AddUint(key string, value uint) {
+	enc.add(zap.Uint(key, value))
+}
+
+func (enc *flattenEncoder) AddUint64(key string, value uint64) {
+	enc.add(zap.Uint64(key, value))
+}
+
+func (enc *flattenEncoder) AddUint32(key string, value uint32) {
+	enc.add(zap.Uint32(key, value))
+}
+
+func (enc *flattenEncoder) AddUint16(key string, value uint16) {
+	enc.add(zap.Uint16(key, value))
+}
+
+func (enc *flattenEncoder) AddUint8(key string, value uint8) {
+	enc.add(zap.Uint8(key, value))
+}
+
+func (enc *flattenEncoder) AddUintptr(key string, value uintptr) {
+	enc.add(zap.Uintptr(key, value))
+}
+
+func (enc *flattenEncoder) AddReflected(key string, value interface{}) error {
+	f := genericField(zap.Reflect(key, value))
+	if f.Type == zapcore.ObjectMarshalerType {
+		return enc.AddObject(key, f.Interface.(zapcore.ObjectMarshaler))
+	}
+	enc.add(f)
+	return nil
+}
+
+func (enc *flattenEncoder) OpenNamespace(key string) {
+	enc.prefix += key + "."
+}
@@ -0,0 +1,121 @@
+// Copyright 2022 The imkuqin-zw Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zap
+
+import (
+	"runtime"
+	"strings"
+)
+
+// loggerPackages are the logging frontends and internals leading the stack
+// of an entry, a trailing "/..." includes the sub-packages.
+var loggerPackages = []string{
+	"github.com/imkuqin-zw/yggdrasil-zap",
+	"github.com/imkuqin-zw/yggdrasil/pkg/logger/...",
+	"go.uber.org/zap/...",
+	"github.com/go-logr/logr/...",
+	"google.golang.org/grpc/grpclog/...",
+	"google.golang.org/grpc/internal/grpclog/...",
+	"log",
+	"log/slog",
+}
+
+// noisyPackages are the packages dropped by the frame filtering.
+var noisyPackages = []string{
+	"runtime/...",
+	"github.com/imkuqin-zw/yggdrasil/...",
+	"github.com/imkuqin-zw/yggdrasil-zap",
+	"go.uber.org/zap/...",
+}
+
+// framePackage returns the import path of the package of a function name
+// such as "github.com/a/b.(*T).Method".
+func framePackage(function string) string {
+	slash := strings.LastIndexByte(function, '/')
+	if dot := strings.IndexByte(function[slash+1:], '.'); dot >= 0 {
+		return function[:slash+1+dot]
+	}
+	return function
+}
+
+func inPackages(frame runtime.Frame, packages []string) bool {
+	// The tests of the packages are never logger internals.
+	if strings.HasSuffix(frame.File, "_test.go") {
+		return false
+	}
+	pkg := framePackage(frame.Function)
+	for _, p := range packages {
+		if prefix := strings.TrimSuffix(p, "/..."); prefix != p {
+			if pkg == prefix || strings.HasPrefix(pkg, prefix+"/") {
+				return true
+			}
+		} else if pkg == p {
+			return true
+		}
+	}
+	return false
+}
+
+// callers returns the frames of the caller of the logger, skip frames are
+// skipped first and the frames of packages leading the stack are dropped.
+func callers(skip int, packages []string) []runtime.Frame {
+	pcs := make([]uintptr, 64)
+	for {
+		n := runtime.Callers(skip+2, pcs)
+		if n < len(pcs) {
+			pcs = pcs[:n]
+			break
+		}
+		pcs = make([]uintptr, len(pcs)*2)
+	}
+	frames := make([]runtime.Frame, 0, len(pcs))
+	iter := runtime.CallersFrames(pcs)
+	leading := true
+	for {
+		frame, more := iter.Next()
+		if leading && !inPackages(frame, packages) {
+			leading = false
+		}
+		if !leading {
+			frames = append(frames, frame)
+		}
+		if !more {
+			break
+		}
+	}
+	return frames
+}
+
+// caller returns the first frame outside of packages, skip frames are
+// skipped first.
+func caller(skip int, packages []string) (runtime.Frame, bool) {
+	pcs := make([]uintptr, 16)
+	for offset := skip + 2; ; offset += len(pcs) {
+		n := runtime.Callers(offset, pcs)
+		iter := runtime.CallersFrames(pcs[:n])
+		for {
+			frame, more := iter.Next()
+			if n > 0 && !inPackages(frame, packages) {
+				return frame, true
+			}
+			if !more {
+				break
+			}
+		}
+		if n < len(pcs) {
+			return runtime.Frame{}, false
+		}
+	}
+}
@@ -0,0 +1,223 @@
+// Copyright 2022 The imkuqin-zw Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zap
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	gcpSourceLocationKey = "logging.googleapis.com/sourceLocation"
+
+	gcpTraceKey = "logging.googleapis.com/trace"
+
+	gcpSpanIDKey = "logging.googleapis.com/spanId"
+
+	gcpLabelsKey = "logging.googleapis.com/labels"
+)
+
+func init() {
+	RegisterEncoder("gcp", func(cfg *Config, encCfg zapcore.EncoderConfig) (zapcore.Encoder, error) {
+		return NewGCPEncoder(encCfg, &cfg.GCP), nil
+	})
+}
+
+type GCPConfig struct {
+	// ProjectID is the project of the traces, it defaults to the
+	// GOOGLE_CLOUD_PROJECT environment variable.
+	ProjectID string
+	// TraceKey is the key of the trace id field, "trace_id" by default.
+	TraceKey string
+	// SpanKey is the key of the span id field, "span_id" by default.
+	SpanKey string
+	// Labels are added to the labels of every entry.
+	Labels map[string]string
+	// LabelKeys are the keys of the fields moved to the labels.
+	LabelKeys []string
+}
+
+// gcpSeverity maps the zap level to the severity of Cloud Logging.
+func gcpSeverity(lv zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
+	switch lv {
+	case zapcore.DebugLevel:
+		enc.AppendString("DEBUG")
+	case zapcore.InfoLevel:
+		enc.AppendString("INFO")
+	case zapcore.WarnLevel:
+		enc.AppendString("WARNING")
+	case zapcore.ErrorLevel:
+		enc.AppendString("ERROR")
+	case zapcore.DPanicLevel:
+		enc.AppendString("CRITICAL")
+	case zapcore.PanicLevel:
+		enc.AppendString("ALERT")
+	case zapcore.FatalLevel:
+		enc.AppendString("EMERGENCY")
+	default:
+		enc.AppendString("DEFAULT")
+	}
+}
+
+// gcpEncoder writes the special fields of Cloud Logging in a head and the
+// user fields, the jsonPayload, in a body merged into the head.
+type gcpEncoder struct {
+	*prefixEncoder
+	head       zapcore.Encoder
+	config     *GCPConfig
+	labelKeys  map[string]struct{}
+	lineEnding string
+}
+
+// NewGCPEncoder returns an encoder writing the structured logs of Google
+// Cloud Logging.
+func NewGCPEncoder(encCfg zapcore.EncoderConfig, config *GCPConfig) zapcore.Encoder {
+	if config.ProjectID == "" {
+		config.ProjectID = os.Getenv("GOOGLE_CLOUD_PROJECT")
+	}
+	if config.TraceKey == "" {
+		config.TraceKey = traceIDKey
+	}
+	if config.SpanKey == "" {
+		config.SpanKey = spanIDKey
+	}
+	labelKeys := make(map[string]struct{}, len(config.LabelKeys))
+	for _, key := range config.LabelKeys {
+		labelKeys[key] = struct{}{}
+	}
+	lineEnding := encCfg.LineEnding
+	if lineEnding == "" {
+		lineEnding = zapcore.DefaultLineEnding
+	}
+	body := encCfg
+	body.TimeKey, body.LevelKey, body.NameKey, body.CallerKey = "", "", "", ""
+	body.FunctionKey, body.MessageKey, body.StacktraceKey = "", "", ""
+	body.SkipLineEnding = true
+	head := body
+	head.TimeKey = "timestamp"
+	head.LevelKey = "severity"
+	head.NameKey = "logger"
+	head.MessageKey = "message"
+	head.StacktraceKey = "stack_trace"
+	head.EncodeTime = zapcore.TimeEncoderOfLayout(time.RFC3339Nano)
+	head.EncodeLevel = gcpSeverity
+	head.EncodeName = zapcore.FullNameEncoder
+	enc := &gcpEncoder{
+		head:       zapcore.NewJSONEncoder(head),
+		config:     config,
+		labelKeys:  labelKeys,
+		lineEnding: lineEnding,
+	}
+	enc.prefixEncoder = newPrefixEncoder(zapcore.NewJSONEncoder(body), enc.ReservedKeys())
+	return enc
+}
+
+// ReservedKeys returns the special keys of Cloud Logging written with the
+// jsonPayload.
+func (enc *gcpEncoder) ReservedKeys() []string {
+	return []string{"timestamp", "severity", "logger", "message", "stack_trace",
+		gcpSourceLocationKey, gcpTraceKey, gcpSpanIDKey, gcpLabelsKey}
+}
+
+func (enc *gcpEncoder) Clone() zapcore.Encoder {
+	clone := *enc
+	clone.prefixEncoder = enc.prefixEncoder.clone()
+	return &clone
+}
+
+func (enc *gcpEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	head := make([]zapcore.Field, 0, 4)
+	if ent.Caller.Defined {
+		caller := ent.Caller
+		head = append(head, zap.Object(gcpSourceLocationKey, zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+			enc.AddString("file", caller.File)
+			enc.AddString("line", strconv.Itoa(caller.Line))
+			if caller.Function != "" {
+				enc.AddString("function", caller.Function)
+			}
+			return nil
+		})))
+	}
+	labels := make(map[string]string, len(enc.config.Labels))
+	for k, v := range enc.config.Labels {
+		labels[k] = v
+	}
+	user := make([]zapcore.Field, 0, len(fields))
+	for _, f := range fields {
+		if _, ok := enc.labelKeys[f.Key]; ok {
+			labels[f.Key] = fieldString(f)
+			continue
+		}
+		switch f.Key {
+		case enc.config.TraceKey:
+			trace := fieldString(f)
+			if enc.config.ProjectID != "" {
+				trace = fmt.Sprintf("projects/%s/traces/%s", enc.config.ProjectID, trace)
+			}
+			head = append(head, zap.String(gcpTraceKey, trace))
+		case enc.config.SpanKey:
+			head = append(head, zap.String(gcpSpanIDKey, fieldString(f)))
+		default:
+			user = append(user, f)
+		}
+	}
+	if len(labels) > 0 {
+		head = append(head, zap.Object(gcpLabelsKey, gcpLabels(labels)))
+	}
+	ent.Caller = zapcore.EntryCaller{}
+
+	headBuf, err := enc.head.EncodeEntry(ent, head)
+	if err != nil {
+		return nil, err
+	}
+	defer headBuf.Free()
+	bodyBuf, err := enc.Encoder.EncodeEntry(zapcore.Entry{}, enc.fields(user))
+	if err != nil {
+		return nil, err
+	}
+	defer bodyBuf.Free()
+	return mergeJSON(headBuf, bodyBuf, "", enc.lineEnding), nil
+}
+
+type gcpLabels map[string]string
+
+func (labels gcpLabels) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		enc.AddString(k, labels[k])
+	}
+	return nil
+}
+
+// fieldString returns the value of a field as a string.
+func fieldString(f zapcore.Field) string {
+	if f.Type == zapcore.StringType {
+		return f.String
+	}
+	m := zapcore.NewMapObjectEncoder()
+	f.AddTo(m)
+	return fmt.Sprint(m.Fields[f.Key])
+}
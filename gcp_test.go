@@ -0,0 +1,91 @@
+// Copyright 2022 The imkuqin-zw Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zap
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/imkuqin-zw/yggdrasil/pkg/logger"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+func Test_GCPEncoder(t *testing.T) {
+	// WriteContext is called directly, not through a yggdrasil logger.
+	cfg := &Config{AddCaller: true, CallerSkip: 1}
+	cfg.Encoding.File = "gcp"
+	cfg.GCP = GCPConfig{
+		ProjectID: "proj",
+		Labels:    map[string]string{"env": "prod"},
+		LabelKeys: []string{"user"},
+	}
+	lg, lines := newTestLogger(t, cfg)
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{1},
+		SpanID:  trace.SpanID{2},
+	})
+	lg.WriteContext(trace.ContextWithSpanContext(context.Background(), sc), logger.LvWarn, "served", "user", "bob", "k", 1)
+
+	entries := lines()
+	if len(entries) != 1 {
+		t.Fatalf("unexpected entries: %q", entries)
+	}
+	got := decodeLine(t, entries[0])
+	if ts, _ := got["timestamp"].(string); ts == "" {
+		t.Errorf("timestamp missing: %v", got)
+	} else if _, err := time.Parse(time.RFC3339Nano, ts); err != nil {
+		t.Errorf("unexpected timestamp: %v", err)
+	}
+	loc, _ := got[gcpSourceLocationKey].(map[string]interface{})
+	if file, _ := loc["file"].(string); filepath.Base(file) != "gcp_test.go" || loc["line"] == "" {
+		t.Errorf("unexpected source location: %v", loc)
+	}
+	want := map[string]interface{}{
+		"severity":   "WARNING",
+		"message":    "served",
+		gcpTraceKey:  "projects/proj/traces/" + sc.TraceID().String(),
+		gcpSpanIDKey: sc.SpanID().String(),
+		gcpLabelsKey: map[string]interface{}{"env": "prod", "user": "bob"},
+		"k":          float64(1),
+	}
+	for k, v := range want {
+		if b1, b2 := mustJSON(t, got[k]), mustJSON(t, v); b1 != b2 {
+			t.Errorf("%s: got %s, want %s", k, b1, b2)
+		}
+	}
+	if len(got) != len(want)+2 {
+		t.Errorf("unexpected keys: %s", entries[0])
+	}
+}
+
+func Test_GCPCollision(t *testing.T) {
+	cfg := &Config{}
+	cfg.Encoding.File = "gcp"
+	lg, lines := newTestLogger(t, cfg)
+	lg.Zap().With(zap.String("severity", "c")).Warn("hello", zap.String("message", "m"))
+
+	line := lines()[0]
+	if strings.Count(line, `"message"`) != 1 || strings.Count(line, `"severity"`) != 1 {
+		t.Fatalf("duplicate keys: %s", line)
+	}
+	got := decodeLine(t, line)
+	if got["severity"] != "WARNING" || got["fields.severity"] != "c" || got["fields.message"] != "m" {
+		t.Errorf("colliding fields not prefixed: %s", line)
+	}
+}
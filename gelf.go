@@ -0,0 +1,301 @@
+// Copyright 2022 The imkuqin-zw Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zap
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	defaultGelfNetwork = "udp"
+
+	defaultGelfAddress = "127.0.0.1:12201"
+
+	defaultGelfChunkSize = 1420
+
+	gelfVersion = "1.1"
+
+	gelfChunkHeaderSize = 12
+
+	gelfMaxChunks = 128
+)
+
+var errGelfTooManyChunks = errors.New("gelf message exceeds the maximum number of chunks")
+
+type GelfConfig struct {
+	// Network is either "udp" or "tcp".
+	Network string
+	Address string
+	// Host is reported as the GELF host, defaults to the hostname.
+	Host string
+	// ChunkSize is the maximum size of a single UDP datagram.
+	ChunkSize int
+	// Compress enables gzip compression of UDP messages.
+	Compress bool
+}
+
+// syslogSeverity maps the zap level to the syslog severity used by GELF.
+func syslogSeverity(lv zapcore.Level) int {
+	switch lv {
+	case zapcore.DebugLevel:
+		return 7
+	case zapcore.InfoLevel:
+		return 6
+	case zapcore.WarnLevel:
+		return 4
+	case zapcore.ErrorLevel:
+		return 3
+	case zapcore.DPanicLevel:
+		return 2
+	case zapcore.PanicLevel:
+		return 1
+	case zapcore.FatalLevel:
+		return 0
+	default:
+		if lv < zapcore.DebugLevel {
+			return 7
+		}
+		return 0
+	}
+}
+
+func gelfEncodeLevel(lv zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
+	enc.AppendInt(syslogSeverity(lv))
+}
+
+// gelfKey turns a field key into a GELF additional field name.
+func gelfKey(key string) string {
+	key = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		case r == '_', r == '-', r == '.':
+			return r
+		default:
+			return '_'
+		}
+	}, key)
+	if key == "id" {
+		// _id is reserved by the GELF spec.
+		return "__id"
+	}
+	return "_" + key
+}
+
+// gelfEncoder flattens the additional fields, GELF only accepts string and
+// number values.
+type gelfEncoder struct {
+	*flattenEncoder
+	json zapcore.Encoder
+	host string
+}
+
+// NewGelfEncoder creates an encoder producing GELF 1.1 messages. The keys of
+// the entry are fixed by the GELF spec, only the name, caller and function
+// keys and the value encoders of cfg are honoured.
+func NewGelfEncoder(cfg zapcore.EncoderConfig, host string) zapcore.Encoder {
+	if host == "" {
+		host, _ = os.Hostname()
+	}
+	cfg.TimeKey = "timestamp"
+	cfg.LevelKey = "level"
+	cfg.MessageKey = "short_message"
+	cfg.StacktraceKey = ""
+	cfg.EncodeTime = zapcore.EpochTimeEncoder
+	cfg.EncodeLevel = gelfEncodeLevel
+	if cfg.NameKey != "" {
+		cfg.NameKey = gelfKey(cfg.NameKey)
+	}
+	if cfg.CallerKey != "" {
+		cfg.CallerKey = gelfKey(cfg.CallerKey)
+	}
+	if cfg.FunctionKey != "" {
+		cfg.FunctionKey = gelfKey(cfg.FunctionKey)
+	}
+	return &gelfEncoder{flattenEncoder: &flattenEncoder{}, json: zapcore.NewJSONEncoder(cfg), host: host}
+}
+
+func (enc *gelfEncoder) Clone() zapcore.Encoder {
+	fields := make([]zapcore.Field, len(enc.fields))
+	copy(fields, enc.fields)
+	return &gelfEncoder{flattenEncoder: &flattenEncoder{prefix: enc.prefix, fields: fields}, json: enc.json, host: enc.host}
+}
+
+func (enc *gelfEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	flat := &flattenEncoder{prefix: enc.prefix, fields: make([]zapcore.Field, len(enc.fields), len(enc.fields)+len(fields))}
+	copy(flat.fields, enc.fields)
+	for _, f := range fields {
+		genericField(f).AddTo(flat)
+	}
+	final := make([]zapcore.Field, 0, len(flat.fields)+3)
+	final = append(final, zap.String("version", gelfVersion), zap.String("host", enc.host))
+	if ent.Stack != "" {
+		final = append(final, zap.String("full_message", ent.Message+"\n"+ent.Stack))
+		ent.Stack = ""
+	}
+	for _, f := range flat.fields {
+		if f, ok := gelfField(f); ok {
+			final = append(final, f)
+		}
+	}
+	return enc.json.EncodeEntry(ent, final)
+}
+
+// gelfField renames a flattened field and converts the values which are
+// neither strings nor numbers to strings, the null values are dropped.
+func gelfField(f zapcore.Field) (zapcore.Field, bool) {
+	key := gelfKey(f.Key)
+	switch f.Type {
+	case zapcore.SkipType:
+		return f, false
+	case zapcore.BoolType:
+		return zap.String(key, strconv.FormatBool(f.Integer == 1)), true
+	case zapcore.ArrayMarshalerType, zapcore.ReflectType:
+		enc := zapcore.NewMapObjectEncoder()
+		f.AddTo(enc)
+		v := enc.Fields[f.Key]
+		if v == nil {
+			return f, false
+		}
+		if s, ok := v.(string); ok {
+			return zap.String(key, s), true
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			return zap.String(key, fmt.Sprint(v)), true
+		}
+		return zap.String(key, string(b)), true
+	}
+	f.Key = key
+	return f, true
+}
+
+// gelfWriter sends every write as one GELF message. The connection is dialed
+// lazily and re-dialed after a failed write.
+type gelfWriter struct {
+	cfg  *GelfConfig
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+func newGelfSyncer(config *GelfConfig) zapcore.WriteSyncer {
+	if config.Network == "" {
+		config.Network = defaultGelfNetwork
+	}
+	if config.Address == "" {
+		config.Address = defaultGelfAddress
+	}
+	if config.ChunkSize <= gelfChunkHeaderSize {
+		config.ChunkSize = defaultGelfChunkSize
+	}
+	return &gelfWriter{cfg: config}
+}
+
+func (w *gelfWriter) Write(p []byte) (int, error) {
+	msg := bytes.TrimRight(p, "\r\n")
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.conn == nil {
+		conn, err := net.Dial(w.cfg.Network, w.cfg.Address)
+		if err != nil {
+			return 0, err
+		}
+		w.conn = conn
+	}
+	var err error
+	if w.cfg.Network == "tcp" {
+		err = w.writeTCP(msg)
+	} else {
+		err = w.writeUDP(msg)
+	}
+	if err != nil {
+		_ = w.conn.Close()
+		w.conn = nil
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (w *gelfWriter) writeTCP(msg []byte) error {
+	// GELF TCP frames are terminated by a null byte.
+	frame := make([]byte, len(msg)+1)
+	copy(frame, msg)
+	_, err := w.conn.Write(frame)
+	return err
+}
+
+func (w *gelfWriter) writeUDP(msg []byte) error {
+	if w.cfg.Compress {
+		buf := &bytes.Buffer{}
+		zw := gzip.NewWriter(buf)
+		if _, err := zw.Write(msg); err != nil {
+			return err
+		}
+		if err := zw.Close(); err != nil {
+			return err
+		}
+		msg = buf.Bytes()
+	}
+	if len(msg) <= w.cfg.ChunkSize {
+		_, err := w.conn.Write(msg)
+		return err
+	}
+	return w.writeChunks(msg)
+}
+
+func (w *gelfWriter) writeChunks(msg []byte) error {
+	dataSize := w.cfg.ChunkSize - gelfChunkHeaderSize
+	count := (len(msg) + dataSize - 1) / dataSize
+	if count > gelfMaxChunks {
+		return fmt.Errorf("%w: %d", errGelfTooManyChunks, count)
+	}
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return err
+	}
+	chunk := make([]byte, 0, w.cfg.ChunkSize)
+	for i := 0; i < count; i++ {
+		end := (i + 1) * dataSize
+		if end > len(msg) {
+			end = len(msg)
+		}
+		chunk = append(chunk[:0], 0x1e, 0x0f)
+		chunk = append(chunk, id...)
+		chunk = append(chunk, byte(i), byte(count))
+		chunk = append(chunk, msg[i*dataSize:end]...)
+		if _, err := w.conn.Write(chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *gelfWriter) Sync() error {
+	return nil
+}
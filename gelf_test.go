@@ -0,0 +1,134 @@
+// Copyright 2022 The imkuqin-zw Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zap
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"math/rand"
+	"net"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func Test_GelfEncoder(t *testing.T) {
+	enc := NewGelfEncoder(zapcore.EncoderConfig{NameKey: "logger"}, "host-1")
+	enc.AddString("ctx", "c")
+	_ = enc.AddReflected("meta", struct{ A int }{1})
+	buf, err := enc.EncodeEntry(zapcore.Entry{
+		Level:      zapcore.ErrorLevel,
+		Time:       time.Unix(1, 500000000),
+		LoggerName: "app",
+		Message:    "boom",
+		Stack:      "main.main()",
+	}, []zapcore.Field{
+		zap.Int("id", 1),
+		zap.String("user name", "u"),
+		zap.Bool("ok", true),
+		zap.Ints("ids", []int{1, 2}),
+		zap.Any("obj", map[string]interface{}{"a": map[string]int{"b": 1}}),
+		zap.Any("ext", json.RawMessage(`{"u":"x"}`)),
+		zap.Reflect("nil", nil),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var msg map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &msg); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"version":       "1.1",
+		"host":          "host-1",
+		"short_message": "boom",
+		"full_message":  "boom\nmain.main()",
+		"timestamp":     1.5,
+		"level":         float64(3),
+		"_logger":       "app",
+		"_ctx":          "c",
+		"__id":          float64(1),
+		"_user_name":    "u",
+		"_meta.A":       float64(1),
+		"_ok":           "true",
+		"_ids":          "[1,2]",
+		"_obj.a.b":      float64(1),
+		"_ext.u":        "x",
+	}
+	for k, v := range want {
+		if msg[k] != v {
+			t.Errorf("%s: got %v, want %v", k, msg[k], v)
+		}
+	}
+	if len(msg) != len(want) {
+		t.Errorf("unexpected keys: %v", msg)
+	}
+}
+
+func Test_GelfWriterUDPChunks(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+
+	ws := newGelfSyncer(&GelfConfig{Address: pc.LocalAddr().String(), ChunkSize: 64, Compress: true})
+	noise := make([]byte, 1024)
+	rand.New(rand.NewSource(1)).Read(noise)
+	payload := []byte(`{"short_message":"` + hex.EncodeToString(noise) + `"}` + "\n")
+	if _, err := ws.Write(payload); err != nil {
+		t.Fatal(err)
+	}
+
+	var chunks [][]byte
+	var count int
+	_ = pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for count == 0 || len(chunks) < count {
+		b := make([]byte, 128)
+		n, _, err := pc.ReadFrom(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n > 64 || b[0] != 0x1e || b[1] != 0x0f {
+			t.Fatalf("invalid chunk: %x", b[:n])
+		}
+		count = int(b[11])
+		chunks = append(chunks, b[:n])
+	}
+	msg := make([]byte, 0)
+	for i := 0; i < count; i++ {
+		for _, c := range chunks {
+			if int(c[10]) == i {
+				msg = append(msg, c[12:]...)
+			}
+		}
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(msg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, bytes.TrimSpace(payload)) {
+		t.Fatalf("unexpected message: %s", got)
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright 2022 The imkuqin-zw Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zap
+
+import (
+	"go.uber.org/zap"
+)
+
+// Zap returns the zap logger of lg, the caller of its methods is reported
+// as the caller of the entries.
+func (lg *Logger) Zap() *zap.Logger {
+	// The caller skip of lg is tuned for Write and its write helper.
+	return lg.sugar.Desugar().WithOptions(zap.AddCallerSkip(-lg.cfg.CallerSkip - 1))
+}
+
+// redirectGlobals redirects the standard library log and replaces the zap
+// globals as configured, it returns a function restoring them.
+func (lg *Logger) redirectGlobals() func() {
+	restores := make([]func(), 0, 2)
+	if lg.cfg.Globals.RedirectStdLog {
+		restores = append(restores, zap.RedirectStdLog(lg.Zap()))
+	}
+	if lg.cfg.Globals.ReplaceGlobals {
+		restores = append(restores, zap.ReplaceGlobals(lg.Zap()))
+	}
+	return func() {
+		for i := len(restores) - 1; i >= 0; i-- {
+			restores[i]()
+		}
+	}
+}
+
+// RestoreGlobals undoes the redirection of the standard library log and
+// the replacement of the zap globals done by NewLogger.
+func (lg *Logger) RestoreGlobals() {
+	if lg.restoreGlobals != nil {
+		lg.restoreGlobals()
+		lg.restoreGlobals = nil
+	}
+}
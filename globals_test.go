@@ -0,0 +1,51 @@
+// Copyright 2022 The imkuqin-zw Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zap
+
+import (
+	"log"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func Test_RedirectGlobals(t *testing.T) {
+	cfg := &Config{AddCaller: true}
+	cfg.Globals.RedirectStdLog = true
+	cfg.Globals.ReplaceGlobals = true
+	lg, lines := newTestLogger(t, cfg)
+	lg.SetLevel(zapcore.InfoLevel)
+
+	log.Print("std")
+	zap.L().Info("global")
+	zap.S().Debug("dropped")
+	lg.RestoreGlobals()
+	zap.L().Info("restored")
+
+	entries := lines()
+	if len(entries) != 2 {
+		t.Fatalf("unexpected entries: %q", entries)
+	}
+	for i, want := range []string{"std", "global"} {
+		got := decodeLine(t, entries[i])
+		caller, _ := got["caller"].(string)
+		if got["msg"] != want || !strings.HasPrefix(filepath.Base(caller), "globals_test.go:") {
+			t.Errorf("unexpected entry %d: %v", i, got)
+		}
+	}
+}
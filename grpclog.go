@@ -0,0 +1,128 @@
+// Copyright 2022 The imkuqin-zw Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zap
+
+import (
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/grpclog"
+)
+
+const defaultGrpcLogName = "grpc"
+
+type GrpcLogConfig struct {
+	// Name is the name of the sub-logger, "grpc" by default.
+	Name string
+	// Verbosity is the highest verbosity reported as enabled by V.
+	Verbosity int
+}
+
+type grpcLogger struct {
+	sugar     *zap.SugaredLogger
+	verbosity int
+}
+
+// NewGrpcLogger returns a grpclog.LoggerV2 writing through the named
+// sub-logger of lg, it implements grpclog.DepthLoggerV2 as well.
+func NewGrpcLogger(lg *Logger, config *GrpcLogConfig) grpclog.LoggerV2 {
+	name := config.Name
+	if name == "" {
+		name = defaultGrpcLogName
+	}
+	lg = lg.Named(name)
+	// grpc calls the logger through one frame of its own package.
+	sugar := lg.Zap().WithOptions(zap.AddCallerSkip(2)).Sugar()
+	return &grpcLogger{sugar: sugar, verbosity: config.Verbosity}
+}
+
+func sprintln(args []interface{}) string {
+	return strings.TrimSuffix(fmt.Sprintln(args...), "\n")
+}
+
+func (l *grpcLogger) depth(depth int) *zap.SugaredLogger {
+	return l.sugar.Desugar().WithOptions(zap.AddCallerSkip(depth)).Sugar()
+}
+
+func (l *grpcLogger) Info(args ...interface{}) {
+	l.sugar.Info(args...)
+}
+
+func (l *grpcLogger) Infoln(args ...interface{}) {
+	l.sugar.Info(sprintln(args))
+}
+
+func (l *grpcLogger) Infof(format string, args ...interface{}) {
+	l.sugar.Infof(format, args...)
+}
+
+func (l *grpcLogger) Warning(args ...interface{}) {
+	l.sugar.Warn(args...)
+}
+
+func (l *grpcLogger) Warningln(args ...interface{}) {
+	l.sugar.Warn(sprintln(args))
+}
+
+func (l *grpcLogger) Warningf(format string, args ...interface{}) {
+	l.sugar.Warnf(format, args...)
+}
+
+func (l *grpcLogger) Error(args ...interface{}) {
+	l.sugar.Error(args...)
+}
+
+func (l *grpcLogger) Errorln(args ...interface{}) {
+	l.sugar.Error(sprintln(args))
+}
+
+func (l *grpcLogger) Errorf(format string, args ...interface{}) {
+	l.sugar.Errorf(format, args...)
+}
+
+func (l *grpcLogger) Fatal(args ...interface{}) {
+	l.sugar.Fatal(args...)
+}
+
+func (l *grpcLogger) Fatalln(args ...interface{}) {
+	l.sugar.Fatal(sprintln(args))
+}
+
+func (l *grpcLogger) Fatalf(format string, args ...interface{}) {
+	l.sugar.Fatalf(format, args...)
+}
+
+func (l *grpcLogger) V(level int) bool {
+	return level <= l.verbosity
+}
+
+func (l *grpcLogger) InfoDepth(depth int, args ...interface{}) {
+	l.depth(depth).Info(sprintln(args))
+}
+
+func (l *grpcLogger) WarningDepth(depth int, args ...interface{}) {
+	l.depth(depth).Warn(sprintln(args))
+}
+
+func (l *grpcLogger) ErrorDepth(depth int, args ...interface{}) {
+	l.depth(depth).Error(sprintln(args))
+}
+
+func (l *grpcLogger) FatalDepth(depth int, args ...interface{}) {
+	l.depth(depth).Fatal(sprintln(args))
+}
+
+var _ grpclog.DepthLoggerV2 = (*grpcLogger)(nil)
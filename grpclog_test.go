@@ -0,0 +1,50 @@
+// Copyright 2022 The imkuqin-zw Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zap
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc/grpclog"
+)
+
+func Test_GrpcLogger(t *testing.T) {
+	lg, lines := newTestLogger(t, &Config{AddCaller: true})
+	lg.SetLevel(zapcore.InfoLevel)
+	grpclog.SetLoggerV2(NewGrpcLogger(lg, &GrpcLogConfig{Verbosity: 1}))
+
+	grpclog.Infof("state %s", "READY")
+	grpclog.Component("transport").Warningf("closing %d", 1)
+	if !grpclog.V(1) || grpclog.V(2) {
+		t.Error("unexpected verbosity")
+	}
+
+	entries := lines()
+	if len(entries) != 2 {
+		t.Fatalf("unexpected entries: %q", entries)
+	}
+	for i, want := range []string{"state READY", "[transport] closing 1"} {
+		got := decodeLine(t, entries[i])
+		if got["msg"] != want || got["Logger"] != "grpc" {
+			t.Errorf("unexpected entry %d: %v", i, got)
+		}
+		if caller, _ := got["caller"].(string); !strings.HasPrefix(filepath.Base(caller), "grpclog_test.go:") {
+			t.Errorf("unexpected caller %d: %s", i, caller)
+		}
+	}
+}
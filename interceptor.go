@@ -0,0 +1,106 @@
+// Copyright 2022 The imkuqin-zw Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zap
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/imkuqin-zw/yggdrasil/pkg/config"
+	"github.com/imkuqin-zw/yggdrasil/pkg/interceptor"
+	"github.com/imkuqin-zw/yggdrasil/pkg/logger"
+	"github.com/imkuqin-zw/yggdrasil/pkg/metadata"
+	"github.com/imkuqin-zw/yggdrasil/pkg/remote/peer"
+	"github.com/imkuqin-zw/yggdrasil/pkg/stream"
+)
+
+const interceptorName = "zap"
+
+func init() {
+	interceptor.RegisterUnaryServerIntBuilder(interceptorName, newUnaryServerInterceptor)
+	interceptor.RegisterStreamServerIntBuilder(interceptorName, newStreamServerInterceptor)
+}
+
+// InterceptorConfig is the config of the zap server interceptors.
+type InterceptorConfig struct {
+	// DebugHeader is the metadata key forcing the debug logs of a request
+	// when its value is "1" or "true".
+	DebugHeader string `default:"x-debug-log"`
+	// RequestIDHeader is the metadata key of the request id, a random id is
+	// generated when the request carries none.
+	RequestIDHeader string `default:"x-request-id"`
+}
+
+func loadInterceptorConfig() *InterceptorConfig {
+	cfg := &InterceptorConfig{}
+	if err := config.Get(fmt.Sprintf(config.KeyInterceptorCfg, interceptorName)).Scan(cfg); err != nil {
+		logger.ErrorField("fault to load zap interceptor config", logger.Err(err))
+	}
+	return cfg
+}
+
+func (cfg *InterceptorConfig) newContext(ctx context.Context, method string) context.Context {
+	md, _ := metadata.FromInContext(ctx)
+	if values := md.Get(cfg.DebugHeader); len(values) > 0 {
+		switch strings.ToLower(values[0]) {
+		case "1", "true":
+			ctx = WithForceDebug(ctx)
+		}
+	}
+	var requestID string
+	if values := md.Get(cfg.RequestIDHeader); len(values) > 0 && values[0] != "" {
+		requestID = values[0]
+	} else {
+		requestID = newRequestID()
+	}
+	kvs := []interface{}{"request_id", requestID, "method", method}
+	if p, ok := peer.PeerFromContext(ctx); ok && p.Addr != nil {
+		kvs = append(kvs, "peer", p.Addr.String())
+	}
+	return WithFields(ctx, kvs...)
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func newUnaryServerInterceptor() interceptor.UnaryServerInterceptor {
+	cfg := loadInterceptorConfig()
+	return func(ctx context.Context, req interface{}, info *interceptor.UnaryServerInfo, handler interceptor.UnaryHandler) (interface{}, error) {
+		return handler(cfg.newContext(ctx, info.FullMethod), req)
+	}
+}
+
+func newStreamServerInterceptor() interceptor.StreamServerInterceptor {
+	cfg := loadInterceptorConfig()
+	return func(srv interface{}, ss stream.ServerStream, info *interceptor.StreamServerInfo, handler stream.StreamHandler) error {
+		return handler(srv, &serverStream{ServerStream: ss, ctx: cfg.newContext(ss.Context(), info.FullMethod)})
+	}
+}
+
+// serverStream overrides the context of the wrapped stream.
+type serverStream struct {
+	stream.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,193 @@
+// Copyright 2022 The imkuqin-zw Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zap
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	defaultJournaldSocket = "/run/systemd/journal/socket"
+
+	journaldMaxFieldName = 64
+)
+
+// journaldReserved are the fields written by the core and the well-known
+// fields of journald, the user fields with these names are prefixed.
+var journaldReserved = map[string]bool{
+	"MESSAGE":           true,
+	"MESSAGE_ID":        true,
+	"PRIORITY":          true,
+	"SYSLOG_IDENTIFIER": true,
+	"SYSLOG_FACILITY":   true,
+	"SYSLOG_PID":        true,
+	"SYSLOG_TIMESTAMP":  true,
+	"LOGGER_NAME":       true,
+	"CODE_FILE":         true,
+	"CODE_LINE":         true,
+	"CODE_FUNC":         true,
+	"STACKTRACE":        true,
+	"ERRNO":             true,
+	"DOCUMENTATION":     true,
+	"TID":               true,
+}
+
+type JournaldConfig struct {
+	// SocketPath is the journald native socket.
+	SocketPath string
+	// Identifier is sent as SYSLOG_IDENTIFIER, defaults to the process name.
+	Identifier string
+}
+
+// journaldCore writes entries to journald using the native protocol, the
+// context fields are kept unencoded because journald has no nesting.
+type journaldCore struct {
+	zapcore.LevelEnabler
+	cfg    *JournaldConfig
+	sender *journaldSender
+	fields []zapcore.Field
+}
+
+func newJournaldCore(config *JournaldConfig, enab zapcore.LevelEnabler) zapcore.Core {
+	if config.SocketPath == "" {
+		config.SocketPath = defaultJournaldSocket
+	}
+	if config.Identifier == "" {
+		config.Identifier = filepath.Base(os.Args[0])
+	}
+	return &journaldCore{
+		LevelEnabler: enab,
+		cfg:          config,
+		sender:       &journaldSender{addr: config.SocketPath},
+	}
+}
+
+func (c *journaldCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := *c
+	clone.fields = make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	clone.fields = append(append(clone.fields, c.fields...), fields...)
+	return &clone
+}
+
+func (c *journaldCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *journaldCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	buf := &bytes.Buffer{}
+	appendJournaldField(buf, "PRIORITY", strconv.Itoa(syslogSeverity(ent.Level)))
+	appendJournaldField(buf, "MESSAGE", ent.Message)
+	appendJournaldField(buf, "SYSLOG_IDENTIFIER", c.cfg.Identifier)
+	if ent.LoggerName != "" {
+		appendJournaldField(buf, "LOGGER_NAME", ent.LoggerName)
+	}
+	if ent.Caller.Defined {
+		appendJournaldField(buf, "CODE_FILE", ent.Caller.File)
+		appendJournaldField(buf, "CODE_LINE", strconv.Itoa(ent.Caller.Line))
+		if ent.Caller.Function != "" {
+			appendJournaldField(buf, "CODE_FUNC", ent.Caller.Function)
+		}
+	}
+	if ent.Stack != "" {
+		appendJournaldField(buf, "STACKTRACE", ent.Stack)
+	}
+
+	enc := zapcore.NewMapObjectEncoder()
+	for i := range c.fields {
+		c.fields[i].AddTo(enc)
+	}
+	for i := range fields {
+		fields[i].AddTo(enc)
+	}
+	keys := make([]string, 0, len(enc.Fields))
+	for k := range enc.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		appendJournaldField(buf, journaldFieldName(k), journaldValue(enc.Fields[k]))
+	}
+	return c.sender.send(buf.Bytes())
+}
+
+func (c *journaldCore) Sync() error {
+	return nil
+}
+
+// journaldFieldName upper-cases key and replaces the characters journald
+// doesn't accept. Leading underscores are reserved for trusted fields, the
+// names of journaldReserved are prefixed.
+func journaldFieldName(key string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			return r
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		default:
+			return '_'
+		}
+	}, key)
+	name = strings.TrimLeft(name, "_")
+	if name == "" || (name[0] >= '0' && name[0] <= '9') || journaldReserved[name] {
+		name = "F_" + name
+	}
+	if len(name) > journaldMaxFieldName {
+		name = name[:journaldMaxFieldName]
+	}
+	return name
+}
+
+func journaldValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case fmt.Stringer:
+		return v.String()
+	}
+	if b, err := json.Marshal(v); err == nil {
+		return string(b)
+	}
+	return fmt.Sprint(v)
+}
+
+// appendJournaldField serializes one field, values containing a newline are
+// written in the binary length-prefixed form.
+func appendJournaldField(buf *bytes.Buffer, name, value string) {
+	buf.WriteString(name)
+	if !strings.ContainsRune(value, '\n') {
+		buf.WriteByte('=')
+		buf.WriteString(value)
+		buf.WriteByte('\n')
+		return
+	}
+	buf.WriteByte('\n')
+	_ = binary.Write(buf, binary.LittleEndian, uint64(len(value)))
+	buf.WriteString(value)
+	buf.WriteByte('\n')
+}
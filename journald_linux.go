@@ -0,0 +1,67 @@
+// Copyright 2022 The imkuqin-zw Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+
+package zap
+
+import (
+	"errors"
+	"net"
+	"os"
+	"sync"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+type journaldSender struct {
+	addr string
+	once sync.Once
+	conn *net.UnixConn
+	err  error
+}
+
+func (s *journaldSender) send(data []byte) error {
+	s.once.Do(func() {
+		s.conn, s.err = net.ListenUnixgram("unixgram", &net.UnixAddr{Net: "unixgram"})
+	})
+	if s.err != nil {
+		return s.err
+	}
+	addr := &net.UnixAddr{Name: s.addr, Net: "unixgram"}
+	_, _, err := s.conn.WriteMsgUnix(data, nil, addr)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, syscall.EMSGSIZE) && !errors.Is(err, syscall.ENOBUFS) {
+		return err
+	}
+	// The entry is too large for a datagram, pass it as a sealed memfd.
+	fd, err := unix.MemfdCreate("journal-entry", unix.MFD_CLOEXEC|unix.MFD_ALLOW_SEALING)
+	if err != nil {
+		return err
+	}
+	file := os.NewFile(uintptr(fd), "journal-entry")
+	defer file.Close()
+	if _, err = file.Write(data); err != nil {
+		return err
+	}
+	seals := unix.F_SEAL_SHRINK | unix.F_SEAL_GROW | unix.F_SEAL_WRITE | unix.F_SEAL_SEAL
+	if _, err = unix.FcntlInt(uintptr(fd), unix.F_ADD_SEALS, seals); err != nil {
+		return err
+	}
+	_, _, err = s.conn.WriteMsgUnix(nil, unix.UnixRights(fd), addr)
+	return err
+}
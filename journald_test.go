@@ -0,0 +1,123 @@
+// Copyright 2022 The imkuqin-zw Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+
+package zap
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/imkuqin-zw/yggdrasil/pkg/logger"
+	"golang.org/x/sys/unix"
+)
+
+func listenJournald(t *testing.T) *net.UnixConn {
+	path := filepath.Join(t.TempDir(), "journal.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+// readJournald reads one entry, following the memfd if one was passed.
+func readJournald(t *testing.T, conn *net.UnixConn) map[string]string {
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	b, oob := make([]byte, 1<<16), make([]byte, 128)
+	n, oobn, _, _, err := conn.ReadMsgUnix(b, oob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := b[:n]
+	if oobn > 0 {
+		msgs, err := unix.ParseSocketControlMessage(oob[:oobn])
+		if err != nil {
+			t.Fatal(err)
+		}
+		fds, err := unix.ParseUnixRights(&msgs[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		file := os.NewFile(uintptr(fds[0]), "memfd")
+		defer file.Close()
+		if _, err = file.Seek(0, io.SeekStart); err != nil {
+			t.Fatal(err)
+		}
+		if data, err = io.ReadAll(file); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fields := map[string]string{}
+	for len(data) > 0 {
+		i := bytes.IndexAny(data, "=\n")
+		name := string(data[:i])
+		if data[i] == '=' {
+			end := bytes.IndexByte(data, '\n')
+			fields[name] = string(data[i+1 : end])
+			data = data[end+1:]
+			continue
+		}
+		size := binary.LittleEndian.Uint64(data[i+1 : i+9])
+		fields[name] = string(data[i+9 : i+9+int(size)])
+		data = data[i+9+int(size)+1:]
+	}
+	return fields
+}
+
+func Test_JournaldCore(t *testing.T) {
+	conn := listenJournald(t)
+	cfg := &Config{Level: "info", AddCaller: true, CallerSkip: 1}
+	cfg.Journald.Enable = true
+	cfg.Journald.SocketPath = conn.LocalAddr().String()
+	cfg.Journald.Identifier = "test"
+	lg := NewLogger(cfg)
+
+	lg.Write(logger.LvWarn, "hello", "request.id", "r-1", "multi", "a\nb", "message", "m", "priority", 7)
+	fields := readJournald(t, conn)
+	want := map[string]string{
+		"PRIORITY":          "4",
+		"MESSAGE":           "hello",
+		"SYSLOG_IDENTIFIER": "test",
+		"REQUEST_ID":        "r-1",
+		"MULTI":             "a\nb",
+		"F_MESSAGE":         "m",
+		"F_PRIORITY":        "7",
+	}
+	for k, v := range want {
+		if fields[k] != v {
+			t.Errorf("%s: got %q, want %q", k, fields[k], v)
+		}
+	}
+	if !strings.HasSuffix(fields["CODE_FILE"], "journald_test.go") || fields["CODE_LINE"] == "" {
+		t.Errorf("unexpected caller: %s:%s", fields["CODE_FILE"], fields["CODE_LINE"])
+	}
+
+	large := strings.Repeat("x", 1<<20)
+	lg.Write(logger.LvError, large)
+	fields = readJournald(t, conn)
+	if fields["MESSAGE"] != large || fields["PRIORITY"] != "3" {
+		t.Errorf("unexpected large entry: %d bytes, priority %q", len(fields["MESSAGE"]), fields["PRIORITY"])
+	}
+}
@@ -0,0 +1,245 @@
+// Copyright 2022 The imkuqin-zw Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zap
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	keysPrefix = "prefix"
+	keysNest   = "nest"
+	keysDrop   = "drop"
+
+	keysSnake = "snake"
+	keysCamel = "camel"
+
+	defaultKeysPrefix = "fields."
+
+	defaultKeysNamespace = "fields"
+)
+
+type KeysConfig struct {
+	// Collision is one of prefix, nest or drop, it applies to the fields
+	// colliding with the keys of the entries, "prefix" by default.
+	Collision string
+	// Prefix is prepended to the colliding keys, "fields." by default.
+	Prefix string
+	// Namespace is the object holding the colliding fields of the nest
+	// collision, "fields" by default.
+	Namespace string
+	// Reserved are added to the keys written by the encoders of the sinks.
+	Reserved []string
+	// Flatten writes the fields of the nested objects and namespaces with
+	// dotted keys.
+	Flatten bool
+	// Case is snake or camel, the keys of the nested objects are normalised
+	// when they are flattened.
+	Case string
+}
+
+type keyRewriter struct {
+	config   *KeysConfig
+	reserved map[string]bool
+	normal   func(string) string
+}
+
+func newKeyRewriter(config *KeysConfig, reserved map[string]bool) (*keyRewriter, error) {
+	if config.Collision == "" {
+		config.Collision = keysPrefix
+	}
+	if config.Prefix == "" {
+		config.Prefix = defaultKeysPrefix
+	}
+	if config.Namespace == "" {
+		config.Namespace = defaultKeysNamespace
+	}
+	switch config.Collision {
+	case keysPrefix, keysNest, keysDrop:
+	default:
+		return nil, fmt.Errorf("unknown key collision: %q", config.Collision)
+	}
+	k := &keyRewriter{config: config, reserved: reserved}
+	switch config.Case {
+	case "":
+	case keysSnake:
+		k.normal = snakeCase
+	case keysCamel:
+		k.normal = camelCase
+	default:
+		return nil, fmt.Errorf("unknown key case: %q", config.Case)
+	}
+	return k, nil
+}
+
+// keysState is carried from the context of a logger to its entries: the
+// prefix of the flattened namespaces, whether a namespace is opened and the
+// colliding context fields of the nest collision, which are written with the
+// colliding fields of the entries in a single object.
+type keysState struct {
+	prefix string
+	inner  bool
+	nested fieldList
+}
+
+// rewrite flattens the fields, normalises their keys and moves the fields
+// colliding with the reserved keys.
+func (k *keyRewriter) rewrite(fields []zapcore.Field, state keysState) ([]zapcore.Field, keysState) {
+	out := make([]zapcore.Field, 0, len(fields))
+	state.nested = state.nested[:len(state.nested):len(state.nested)]
+	add := func(f zapcore.Field) {
+		if k.normal != nil {
+			f.Key = k.normal(f.Key)
+		}
+		// The fields of an opened namespace never collide.
+		if state.inner || !k.reserved[f.Key] {
+			out = append(out, f)
+			return
+		}
+		switch k.config.Collision {
+		case keysPrefix:
+			f.Key = k.config.Prefix + f.Key
+			out = append(out, f)
+		case keysNest:
+			state.nested = append(state.nested, f)
+		}
+	}
+	for _, f := range fields {
+		if !k.config.Flatten {
+			if f.Type == zapcore.NamespaceType && !state.inner {
+				// The nested fields can no longer be written at the top level.
+				if len(state.nested) > 0 {
+					out = append(out, zap.Object(k.config.Namespace, state.nested))
+					state.nested = nil
+				}
+				add(f)
+				state.inner = true
+				continue
+			}
+			add(f)
+			continue
+		}
+		f = genericField(f)
+		switch f.Type {
+		case zapcore.NamespaceType:
+			state.prefix += f.Key + "."
+		case zapcore.ObjectMarshalerType, zapcore.InlineMarshalerType:
+			flat := &flattenEncoder{prefix: state.prefix}
+			if f.Type == zapcore.ObjectMarshalerType {
+				flat.prefix += f.Key + "."
+			}
+			if err := f.Interface.(zapcore.ObjectMarshaler).MarshalLogObject(flat); err != nil {
+				flat.fields = append(flat.fields, zap.String(state.prefix+f.Key+"Error", err.Error()))
+			}
+			for _, item := range flat.fields {
+				add(item)
+			}
+		default:
+			f.Key = state.prefix + f.Key
+			add(f)
+		}
+	}
+	return out, state
+}
+
+// keysCore rewrites the keys of the fields of the logger and of the entries.
+type keysCore struct {
+	zapcore.Core
+	k     *keyRewriter
+	state keysState
+}
+
+func newKeysCore(core zapcore.Core, k *keyRewriter) zapcore.Core {
+	return &keysCore{Core: core, k: k}
+}
+
+func (c *keysCore) With(fields []zapcore.Field) zapcore.Core {
+	out, state := c.k.rewrite(fields, c.state)
+	return &keysCore{Core: c.Core.With(out), k: c.k, state: state}
+}
+
+func (c *keysCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *keysCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	out, state := c.k.rewrite(fields, c.state)
+	if len(state.nested) > 0 {
+		out = append(out, zap.Object(c.k.config.Namespace, state.nested))
+	}
+	return c.Core.Write(ent, out)
+}
+
+type fieldList []zapcore.Field
+
+func (fields fieldList) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	return nil
+}
+
+// snakeCase converts the segments of a dotted key to snake_case.
+func snakeCase(key string) string {
+	runes := []rune(key)
+	var b strings.Builder
+	for i, r := range runes {
+		switch {
+		case r == '-' || r == ' ':
+			b.WriteByte('_')
+		case unicode.IsUpper(r):
+			if i > 0 && runes[i-1] != '.' && runes[i-1] != '_' && runes[i-1] != '-' &&
+				(!unicode.IsUpper(runes[i-1]) || i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+				b.WriteByte('_')
+			}
+			b.WriteRune(unicode.ToLower(r))
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// camelCase converts the segments of a dotted key to camelCase.
+func camelCase(key string) string {
+	var b strings.Builder
+	upper, start := false, true
+	for _, r := range key {
+		switch {
+		case r == '_' || r == '-' || r == ' ':
+			upper = !start
+		case r == '.':
+			b.WriteRune(r)
+			upper, start = false, true
+		case start:
+			b.WriteRune(unicode.ToLower(r))
+			start = false
+		case upper:
+			b.WriteRune(unicode.ToUpper(r))
+			upper = false
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
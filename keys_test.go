@@ -0,0 +1,134 @@
+// Copyright 2022 The imkuqin-zw Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zap
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/imkuqin-zw/yggdrasil/pkg/logger"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type keysUser struct{}
+
+func (keysUser) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("firstName", "bob")
+	enc.OpenNamespace("home")
+	enc.AddInt("zipCode", 1)
+	return nil
+}
+
+func Test_KeyCollision(t *testing.T) {
+	cases := []struct {
+		collision string
+		want      map[string]interface{}
+	}{
+		{keysPrefix, map[string]interface{}{"fields.lv": "x", "fields.msg": "m"}},
+		{keysDrop, map[string]interface{}{}},
+		{keysNest, map[string]interface{}{"fields": map[string]interface{}{"lv": "x", "msg": "m"}}},
+	}
+	for _, c := range cases {
+		cfg := &Config{}
+		cfg.Keys.Enable = true
+		cfg.Keys.Collision = c.collision
+		lg, lines := newTestLogger(t, cfg)
+		lg.Zap().With(zap.String("lv", "x")).Info("hello", zap.String("msg", "m"), zap.Int("k", 1))
+
+		line := lines()[0]
+		if strings.Count(line, `"fields"`) > 1 {
+			t.Errorf("%s: duplicate namespaces: %s", c.collision, line)
+		}
+		got := decodeLine(t, line)
+		if got["msg"] != "hello" || got["lv"] != "info" || got["k"] != float64(1) {
+			t.Errorf("%s: entry keys overridden: %s", c.collision, line)
+		}
+		for key, want := range c.want {
+			if mustJSON(t, got[key]) != mustJSON(t, want) {
+				t.Errorf("%s: unexpected %s: %s", c.collision, key, line)
+			}
+		}
+		if len(got) != len(c.want)+4 {
+			t.Errorf("%s: unexpected fields: %s", c.collision, line)
+		}
+	}
+}
+
+func Test_KeyFlatten(t *testing.T) {
+	cfg := &Config{}
+	cfg.Keys.Enable = true
+	cfg.Keys.Flatten = true
+	cfg.Keys.Case = keysSnake
+	lg, lines := newTestLogger(t, cfg)
+
+	lg.Write(logger.LvInfo, "kvs", "user", keysUser{}, "userObj", map[string]interface{}{"a": map[string]int{"bB": 1}})
+	logger.NewLogger(logger.LvDebug, lg).InfoField("ext", logger.String("firstName", "bob"))
+	lg.Zap().With(zap.Namespace("req")).Info("namespace", zap.String("HTTPId", "x"), zap.String("msg", "m"))
+
+	want := []map[string]interface{}{
+		{"user.first_name": "bob", "user.home.zip_code": float64(1), "user_obj.a.b_b": float64(1)},
+		{"ext.first_name": "bob"},
+		{"req.http_id": "x", "req.msg": "m"},
+	}
+	got := lines()
+	if len(got) != len(want) {
+		t.Fatalf("unexpected lines: %v", got)
+	}
+	for i, line := range got {
+		fields := decodeLine(t, line)
+		for key, value := range want[i] {
+			if fields[key] != value {
+				t.Errorf("unexpected %s: %s", key, line)
+			}
+		}
+		if len(fields) != len(want[i])+3 {
+			t.Errorf("unexpected fields: %s", line)
+		}
+	}
+}
+
+func Test_KeyCase(t *testing.T) {
+	for key, want := range map[string]string{"userID": "user_id", "HTTPServer.maxConns": "http_server.max_conns", "a-b": "a_b"} {
+		if got := snakeCase(key); got != want {
+			t.Errorf("snakeCase(%q) = %q, want %q", key, got, want)
+		}
+	}
+	for key, want := range map[string]string{"user_id": "userId", "Req.max-conns": "req.maxConns", "_a": "a"} {
+		if got := camelCase(key); got != want {
+			t.Errorf("camelCase(%q) = %q, want %q", key, got, want)
+		}
+	}
+	if _, err := newKeyRewriter(&KeysConfig{Collision: "rename"}, nil); err == nil {
+		t.Error("unknown collision accepted")
+	}
+}
+
+func Test_KeyEncoderReserved(t *testing.T) {
+	cfg := &Config{}
+	cfg.Encoding.File = "ecs"
+	cfg.Keys.Enable = true
+	lg, lines := newTestLogger(t, cfg)
+	lg.Zap().Info("hello", zap.String("message", "m"), zap.String("msg", "x"))
+
+	line := lines()[0]
+	if strings.Count(line, `"message"`) != 1 {
+		t.Fatalf("duplicate keys: %s", line)
+	}
+	got := decodeLine(t, line)
+	if got["message"] != "hello" || got["fields.message"] != "m" || got["msg"] != "x" {
+		t.Errorf("keys of the encoding not reserved: %s", line)
+	}
+}
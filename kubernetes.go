@@ -0,0 +1,115 @@
+// Copyright 2022 The imkuqin-zw Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zap
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	defaultKubernetesKey = "k8s"
+
+	defaultProcRoot = "/proc"
+)
+
+// kubernetesEnvs maps the environment variables usually set with the
+// downward API to the keys of the fields.
+var kubernetesEnvs = []struct{ env, key string }{
+	{"POD_NAME", "pod"},
+	{"POD_NAMESPACE", "namespace"},
+	{"POD_UID", "pod_uid"},
+	{"POD_IP", "pod_ip"},
+	{"NODE_NAME", "node"},
+	{"CONTAINER_NAME", "container"},
+}
+
+var (
+	cgroupContainerID    = regexp.MustCompile(`[0-9a-f]{64}`)
+	mountinfoContainerID = regexp.MustCompile(`/(?:containers|sandboxes)/([0-9a-f]{64})/`)
+)
+
+type KubernetesConfig struct {
+	// Key nests the fields under it, "k8s" by default, "-" adds them at the
+	// top level.
+	Key string
+	// ProcRoot is the mount point of procfs, "/proc" by default.
+	ProcRoot string
+}
+
+// kubernetesFields returns the fields of the pod and the container, none
+// when the process is not running in a container.
+func (config *KubernetesConfig) kubernetesFields() []zapcore.Field {
+	if config.Key == "" {
+		config.Key = defaultKubernetesKey
+	}
+	if config.ProcRoot == "" {
+		config.ProcRoot = defaultProcRoot
+	}
+	fields := make([]zapcore.Field, 0, len(kubernetesEnvs)+1)
+	for _, item := range kubernetesEnvs {
+		if value := os.Getenv(item.env); value != "" {
+			fields = append(fields, zap.String(item.key, value))
+		}
+	}
+	if id := containerID(config.ProcRoot); id != "" {
+		fields = append(fields, zap.String("container_id", id))
+	}
+	if len(fields) == 0 || config.Key == omitStaticKey {
+		return fields
+	}
+	return []zapcore.Field{zap.Object(config.Key, zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+		for _, f := range fields {
+			f.AddTo(enc)
+		}
+		return nil
+	}))}
+}
+
+// containerID reads the id of the container from the cgroups of the process,
+// or from its mounts when the cgroup namespace hides it.
+func containerID(procRoot string) string {
+	if id := scanFile(filepath.Join(procRoot, "self", "cgroup"), func(line string) string {
+		return cgroupContainerID.FindString(line)
+	}); id != "" {
+		return id
+	}
+	return scanFile(filepath.Join(procRoot, "self", "mountinfo"), func(line string) string {
+		if m := mountinfoContainerID.FindStringSubmatch(line); m != nil {
+			return m[1]
+		}
+		return ""
+	})
+}
+
+func scanFile(path string, match func(line string) string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if id := match(scanner.Text()); id != "" {
+			return id
+		}
+	}
+	return ""
+}
@@ -0,0 +1,72 @@
+// Copyright 2022 The imkuqin-zw Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zap
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func fakeProcRoot(t *testing.T, files map[string]string) string {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "self"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(root, "self", name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func Test_KubernetesFields(t *testing.T) {
+	id := strings.Repeat("ab", 32)
+	for _, env := range kubernetesEnvs {
+		t.Setenv(env.env, "")
+	}
+
+	root := fakeProcRoot(t, map[string]string{"cgroup": "0::/\n"})
+	if fields := (&KubernetesConfig{ProcRoot: root}).kubernetesFields(); len(fields) != 0 {
+		t.Fatalf("unexpected fields outside a container: %v", fields)
+	}
+
+	t.Setenv("POD_NAME", "api-0")
+	t.Setenv("POD_NAMESPACE", "prod")
+	root = fakeProcRoot(t, map[string]string{
+		"cgroup":    "0::/\n",
+		"mountinfo": "1 0 8:1 /var/lib/containerd/io.containerd.grpc.v1.cri/sandboxes/" + id + "/hostname /etc/hostname rw - ext4 /dev/sda1 rw\n",
+	})
+	obs, logs := observer.New(zapcore.DebugLevel)
+	zap.New(sinkCore{obs}.With((&KubernetesConfig{ProcRoot: root}).kubernetesFields())).Info("k8s")
+	want := map[string]interface{}{
+		"k8s": map[string]interface{}{"pod": "api-0", "namespace": "prod", "container_id": id},
+	}
+	if got := logs.All()[0].ContextMap(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	root = fakeProcRoot(t, map[string]string{"cgroup": "12:memory:/kubepods/burstable/pod1/" + id + "\n"})
+	if id2 := containerID(root); id2 != id {
+		t.Fatalf("unexpected container id: %q", id2)
+	}
+}
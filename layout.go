@@ -0,0 +1,350 @@
+// Copyright 2022 The imkuqin-zw Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zap
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+const defaultLayoutTemplate = "{time} {level:5} [{logger}] {caller} {message} {fields}"
+
+func init() {
+	RegisterEncoder("layout", func(cfg *Config, encCfg zapcore.EncoderConfig) (zapcore.Encoder, error) {
+		return NewLayoutEncoder(encCfg, &cfg.Layout)
+	})
+}
+
+var layoutPool = buffer.NewPool()
+
+var layoutColors = map[string]string{
+	"black":   "\x1b[30m",
+	"red":     "\x1b[31m",
+	"green":   "\x1b[32m",
+	"yellow":  "\x1b[33m",
+	"blue":    "\x1b[34m",
+	"magenta": "\x1b[35m",
+	"cyan":    "\x1b[36m",
+	"white":   "\x1b[37m",
+	"gray":    "\x1b[90m",
+	"bold":    "\x1b[1m",
+	"faint":   "\x1b[2m",
+}
+
+var layoutParts = map[string]bool{
+	"time":     true,
+	"level":    true,
+	"logger":   true,
+	"caller":   true,
+	"function": true,
+	"message":  true,
+	"fields":   true,
+	"stack":    true,
+}
+
+type LayoutConfig struct {
+	// Template is the layout of a line, the parts are written as {name} or
+	// {name:width}, a positive width pads on the right and a negative width
+	// pads on the left. The parts are time, level, logger, caller, function,
+	// message, fields and stack, the stack is written on the following lines
+	// when the template does not hold it. The text touching an empty part,
+	// e.g. the brackets of "[{logger}]", is dropped with it.
+	Template string
+	// Colors maps the parts to a color: black, red, green, yellow, blue,
+	// magenta, cyan, white, gray, bold or faint.
+	Colors map[string]string
+	// Pretty writes the fields as indented JSON on the following lines.
+	Pretty bool
+}
+
+// layoutPart is a literal text when name is empty. The literal texts around
+// the parts are attached to them: prefix and suffix touch the part, sep is the
+// separator from the previous part. They are not written when the part is
+// empty.
+type layoutPart struct {
+	text   string
+	name   string
+	width  int
+	color  string
+	prefix string
+	suffix string
+	sep    string
+}
+
+func parseLayout(cfg *LayoutConfig) ([]layoutPart, error) {
+	tpl := cfg.Template
+	if tpl == "" {
+		tpl = defaultLayoutTemplate
+	}
+	var parts []layoutPart
+	for tpl != "" {
+		start := strings.IndexByte(tpl, '{')
+		if start < 0 {
+			parts = append(parts, layoutPart{text: tpl})
+			break
+		}
+		if start > 0 {
+			parts = append(parts, layoutPart{text: tpl[:start]})
+		}
+		end := strings.IndexByte(tpl[start:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("unclosed part in layout %q", cfg.Template)
+		}
+		part := layoutPart{name: tpl[start+1 : start+end]}
+		if idx := strings.IndexByte(part.name, ':'); idx >= 0 {
+			width, err := strconv.Atoi(part.name[idx+1:])
+			if err != nil {
+				return nil, fmt.Errorf("invalid width of layout part %q", part.name)
+			}
+			part.name, part.width = part.name[:idx], width
+		}
+		if !layoutParts[part.name] {
+			return nil, fmt.Errorf("unknown layout part %q", part.name)
+		}
+		if name, ok := cfg.Colors[part.name]; ok {
+			if part.color, ok = layoutColors[name]; !ok {
+				return nil, fmt.Errorf("unknown color %q", name)
+			}
+		}
+		parts = append(parts, part)
+		tpl = tpl[start+end+1:]
+	}
+	return attachLiterals(parts), nil
+}
+
+// attachLiterals attaches the literal texts to the parts around them, a text
+// between two parts is split at its spaces: the characters before the first
+// space are the suffix of the previous part, the ones after the last space
+// the prefix of the next one, and the rest is the separator.
+func attachLiterals(raw []layoutPart) []layoutPart {
+	parts := make([]layoutPart, 0, len(raw))
+	for i := 0; i < len(raw); i++ {
+		part := raw[i]
+		if part.name != "" {
+			parts = append(parts, part)
+			continue
+		}
+		prev := len(parts) > 0 && parts[len(parts)-1].name != ""
+		next := i+1 < len(raw)
+		switch {
+		case prev && next:
+			first, last := strings.IndexByte(part.text, ' '), strings.LastIndexByte(part.text, ' ')
+			if first < 0 {
+				raw[i+1].sep = part.text
+				continue
+			}
+			parts[len(parts)-1].suffix += part.text[:first]
+			raw[i+1].sep = part.text[first : last+1]
+			raw[i+1].prefix = part.text[last+1:]
+		case next:
+			raw[i+1].prefix = part.text
+		case prev:
+			parts[len(parts)-1].suffix += part.text
+		default:
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
+// layoutEncoder writes the entries following a template, the fields are
+// encoded by the embedded JSON encoder holding the context.
+type layoutEncoder struct {
+	zapcore.Encoder
+	cfg    *zapcore.EncoderConfig
+	parts  []layoutPart
+	pretty bool
+	stack  bool
+}
+
+// NewLayoutEncoder returns an encoder writing the entries following the
+// template of the layout config.
+func NewLayoutEncoder(encCfg zapcore.EncoderConfig, cfg *LayoutConfig) (zapcore.Encoder, error) {
+	parts, err := parseLayout(cfg)
+	if err != nil {
+		return nil, err
+	}
+	enc := &layoutEncoder{cfg: &encCfg, parts: parts, pretty: cfg.Pretty}
+	for _, part := range parts {
+		enc.stack = enc.stack || part.name == "stack"
+	}
+	enc.Encoder = zapcore.NewJSONEncoder(zapcore.EncoderConfig{
+		SkipLineEnding:      true,
+		EncodeTime:          encCfg.EncodeTime,
+		EncodeDuration:      encCfg.EncodeDuration,
+		NewReflectedEncoder: encCfg.NewReflectedEncoder,
+	})
+	return enc, nil
+}
+
+func (enc *layoutEncoder) Clone() zapcore.Encoder {
+	clone := *enc
+	clone.Encoder = enc.Encoder.Clone()
+	return &clone
+}
+
+func (enc *layoutEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	line := layoutPool.Get()
+	defer line.Free()
+	wrote := false
+	for _, part := range enc.parts {
+		if part.name == "" {
+			line.AppendString(part.text)
+			continue
+		}
+		value, err := enc.part(part.name, ent, fields)
+		if err != nil {
+			return nil, err
+		}
+		// The literal texts of the empty parts are dropped with them.
+		if value == "" {
+			continue
+		}
+		if wrote {
+			line.AppendString(part.sep)
+		}
+		wrote = true
+		line.AppendString(part.prefix)
+		if part.color != "" {
+			line.AppendString(part.color)
+		}
+		if part.width < 0 {
+			padLayout(line, value, -part.width)
+		}
+		line.AppendString(value)
+		if part.width > 0 {
+			padLayout(line, value, part.width)
+		}
+		if part.color != "" {
+			line.AppendString("\x1b[0m")
+		}
+		line.AppendString(part.suffix)
+	}
+	// the trailing parts may be empty.
+	out := layoutPool.Get()
+	out.Write(bytes.TrimRight(line.Bytes(), " "))
+	if !enc.stack && ent.Stack != "" && enc.cfg.StacktraceKey != "" {
+		out.AppendByte('\n')
+		out.AppendString(ent.Stack)
+	}
+	if enc.cfg.SkipLineEnding {
+		return out, nil
+	}
+	if enc.cfg.LineEnding != "" {
+		out.AppendString(enc.cfg.LineEnding)
+	} else {
+		out.AppendString(zapcore.DefaultLineEnding)
+	}
+	return out, nil
+}
+
+func (enc *layoutEncoder) part(name string, ent zapcore.Entry, fields []zapcore.Field) (string, error) {
+	arr := &logfmtArray{cfg: enc.cfg}
+	switch name {
+	case "time":
+		if enc.cfg.TimeKey != "" && !ent.Time.IsZero() {
+			arr.AppendTime(ent.Time)
+		}
+	case "level":
+		if enc.cfg.LevelKey != "" {
+			encodeLevel := enc.cfg.EncodeLevel
+			if encodeLevel == nil {
+				encodeLevel = zapcore.CapitalLevelEncoder
+			}
+			encodeLevel(ent.Level, arr)
+		}
+	case "logger":
+		if enc.cfg.NameKey != "" && ent.LoggerName != "" {
+			encodeName := enc.cfg.EncodeName
+			if encodeName == nil {
+				encodeName = zapcore.FullNameEncoder
+			}
+			encodeName(ent.LoggerName, arr)
+		}
+	case "caller":
+		if enc.cfg.CallerKey != "" && ent.Caller.Defined {
+			encodeCaller := enc.cfg.EncodeCaller
+			if encodeCaller == nil {
+				encodeCaller = zapcore.ShortCallerEncoder
+			}
+			encodeCaller(ent.Caller, arr)
+		}
+	case "function":
+		if ent.Caller.Defined {
+			arr.AppendString(ent.Caller.Function)
+		}
+	case "message":
+		if enc.cfg.MessageKey != "" {
+			arr.AppendString(ent.Message)
+		}
+	case "fields":
+		return enc.fields(fields)
+	case "stack":
+		if enc.cfg.StacktraceKey != "" && ent.Stack != "" {
+			arr.AppendString("\n" + ent.Stack)
+		}
+	}
+	return arr.value(), nil
+}
+
+func (enc *layoutEncoder) fields(fields []zapcore.Field) (string, error) {
+	buf, err := enc.Encoder.EncodeEntry(zapcore.Entry{}, fields)
+	if err != nil {
+		return "", err
+	}
+	defer buf.Free()
+	if buf.Len() <= 2 {
+		return "", nil
+	}
+	if enc.pretty {
+		indented := &bytes.Buffer{}
+		if err := json.Indent(indented, buf.Bytes(), "", "  "); err == nil {
+			return "\n" + indented.String(), nil
+		}
+	}
+	return buf.String(), nil
+}
+
+// padLayout pads the value to width, the escape sequences of the colors
+// written by the encoders of the config take no room.
+func padLayout(out *buffer.Buffer, value string, width int) {
+	for n := width - visibleLen(value); n > 0; n-- {
+		out.AppendByte(' ')
+	}
+}
+
+func visibleLen(value string) int {
+	n := 0
+	for i := 0; i < len(value); {
+		if value[i] == '\x1b' {
+			end := strings.IndexByte(value[i:], 'm')
+			if end >= 0 {
+				i += end + 1
+				continue
+			}
+		}
+		_, size := utf8.DecodeRuneInString(value[i:])
+		i += size
+		n++
+	}
+	return n
+}
@@ -0,0 +1,103 @@
+// Copyright 2022 The imkuqin-zw Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zap
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/imkuqin-zw/yggdrasil/pkg/logger"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func Test_LayoutEncoder(t *testing.T) {
+	encCfg := zapcore.EncoderConfig{
+		TimeKey:       "ts",
+		LevelKey:      "lv",
+		NameKey:       "Logger",
+		CallerKey:     "caller",
+		MessageKey:    "msg",
+		StacktraceKey: "stack",
+		EncodeTime: func(t time.Time, enc zapcore.PrimitiveArrayEncoder) {
+			enc.AppendString(t.Format("15:04:05"))
+		},
+		EncodeLevel: zapcore.CapitalColorLevelEncoder,
+	}
+	ent := zapcore.Entry{
+		Level:      zapcore.InfoLevel,
+		Time:       time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC),
+		LoggerName: "app",
+		Message:    "hello",
+		Caller:     zapcore.NewEntryCaller(0, "/src/pkg/main.go", 42, true),
+	}
+
+	enc, err := NewLayoutEncoder(encCfg, &LayoutConfig{Colors: map[string]string{"logger": "cyan"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc.AddString("ctx", "v")
+	buf, err := enc.EncodeEntry(ent, []zapcore.Field{zap.Int("k", 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "03:04:05 \x1b[34mINFO\x1b[0m  [\x1b[36mapp\x1b[0m] pkg/main.go:42 hello {\"ctx\":\"v\",\"k\":1}\n"
+	if got := buf.String(); got != want {
+		t.Fatalf("\ngot:  %q\nwant: %q", got, want)
+	}
+
+	ent.Stack = "main.main"
+	enc, err = NewLayoutEncoder(encCfg, &LayoutConfig{Template: "{level:-6}|{message}{fields}", Pretty: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf, err = enc.EncodeEntry(ent, []zapcore.Field{zap.Int("k", 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = "  \x1b[34mINFO\x1b[0m|hello\n{\n  \"k\": 1\n}\nmain.main\n"
+	if got := buf.String(); got != want {
+		t.Fatalf("\ngot:  %q\nwant: %q", got, want)
+	}
+
+	for _, tpl := range []string{"{unknown}", "{level:x}", "{level"} {
+		if _, err := NewLayoutEncoder(encCfg, &LayoutConfig{Template: tpl}); err == nil {
+			t.Errorf("template %q accepted", tpl)
+		}
+	}
+	if _, err := NewLayoutEncoder(encCfg, &LayoutConfig{Colors: map[string]string{"level": "pink"}}); err == nil {
+		t.Error("unknown color accepted")
+	}
+}
+
+func Test_LayoutEmptyParts(t *testing.T) {
+	cfg := &Config{}
+	cfg.Encoding.File = "layout"
+	lg, lines := newTestLogger(t, cfg)
+	lg.Write(logger.LvInfo, "hello")
+	lg.Named("app").Write(logger.LvWarn, "named", "k", 1)
+
+	got := lines()
+	if len(got) != 2 {
+		t.Fatalf("unexpected lines: %q", got)
+	}
+	if _, line, _ := strings.Cut(got[0], " "); line != "info  hello" {
+		t.Errorf("literals of the empty parts written: %q", got[0])
+	}
+	if _, line, _ := strings.Cut(got[1], " "); line != `warn  [app] named {"k":1}` {
+		t.Errorf("unexpected line: %q", got[1])
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright 2022 The imkuqin-zw Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zap
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+var errEmptyLevelName = errors.New("level rule requires a logger name")
+
+// levelRules is an immutable snapshot of the rules, the resolved names are
+// cached until the rules change.
+type levelRules struct {
+	rules map[string]zapcore.Level
+	min   zapcore.Level
+	cache sync.Map
+}
+
+// levels resolves the level of a logger name hierarchically: the rule with
+// the longest matching name prefix wins, the root level applies otherwise.
+type levels struct {
+	root       *zap.AtomicLevel
+	mu         sync.Mutex
+	rules      atomic.Value
+	elevations *elevations
+}
+
+func newLevels(root *zap.AtomicLevel, rules map[string]string) (*levels, error) {
+	lvs := &levels{root: root, elevations: &elevations{items: map[string]*elevation{}}}
+	parsed := make(map[string]zapcore.Level, len(rules))
+	for name, text := range rules {
+		if name == "" {
+			return nil, errEmptyLevelName
+		}
+		var lv zapcore.Level
+		if err := lv.UnmarshalText([]byte(text)); err != nil {
+			return nil, err
+		}
+		parsed[name] = lv
+	}
+	lvs.store(parsed)
+	return lvs, nil
+}
+
+func (lvs *levels) load() *levelRules {
+	return lvs.rules.Load().(*levelRules)
+}
+
+func (lvs *levels) store(rules map[string]zapcore.Level) {
+	snapshot := &levelRules{rules: rules, min: zapcore.FatalLevel}
+	for _, lv := range rules {
+		if lv < snapshot.min {
+			snapshot.min = lv
+		}
+	}
+	lvs.rules.Store(snapshot)
+}
+
+// Enabled reports whether any logger may write an entry at lvl, it is the
+// fast path used before the name of the logger is known.
+func (lvs *levels) Enabled(lvl zapcore.Level) bool {
+	if lvs.root.Enabled(lvl) {
+		return true
+	}
+	snapshot := lvs.load()
+	return len(snapshot.rules) > 0 && snapshot.min <= lvl
+}
+
+func (lvs *levels) EnabledFor(name string, lvl zapcore.Level) bool {
+	return lvs.LevelFor(name) <= lvl
+}
+
+// LevelFor returns the effective level of the named logger.
+func (lvs *levels) LevelFor(name string) zapcore.Level {
+	snapshot := lvs.load()
+	if len(snapshot.rules) == 0 {
+		return lvs.root.Level()
+	}
+	if lv, ok := snapshot.cache.Load(name); ok {
+		if lv == nil {
+			return lvs.root.Level()
+		}
+		return lv.(zapcore.Level)
+	}
+	for prefix := name; prefix != ""; {
+		if lv, ok := snapshot.rules[prefix]; ok {
+			snapshot.cache.Store(name, lv)
+			return lv
+		}
+		idx := strings.LastIndexByte(prefix, '.')
+		if idx < 0 {
+			break
+		}
+		prefix = prefix[:idx]
+	}
+	// nil marks the names following the root level, which may still change.
+	snapshot.cache.Store(name, nil)
+	return lvs.root.Level()
+}
+
+func (lvs *levels) SetLevelFor(name string, lv zapcore.Level) error {
+	if name == "" {
+		return errEmptyLevelName
+	}
+	lvs.mu.Lock()
+	defer lvs.mu.Unlock()
+	rules := lvs.copyRules()
+	rules[name] = lv
+	lvs.store(rules)
+	return nil
+}
+
+func (lvs *levels) UnsetLevelFor(name string) {
+	lvs.mu.Lock()
+	defer lvs.mu.Unlock()
+	rules := lvs.copyRules()
+	delete(rules, name)
+	lvs.store(rules)
+}
+
+// Rules returns a copy of the configured rules.
+func (lvs *levels) Rules() map[string]zapcore.Level {
+	lvs.mu.Lock()
+	defer lvs.mu.Unlock()
+	return lvs.copyRules()
+}
+
+func (lvs *levels) copyRules() map[string]zapcore.Level {
+	current := lvs.load().rules
+	rules := make(map[string]zapcore.Level, len(current)+1)
+	for name, lv := range current {
+		rules[name] = lv
+	}
+	return rules
+}
@@ -0,0 +1,76 @@
+// Copyright 2022 The imkuqin-zw Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zap
+
+import (
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func Test_Levels(t *testing.T) {
+	root := zap.NewAtomicLevelAt(zapcore.InfoLevel)
+	lvs, err := newLevels(&root, map[string]string{
+		"yggdrasil.remote": "warn",
+		"app.billing":      "debug",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := map[string]zapcore.Level{
+		"":                        zapcore.InfoLevel,
+		"yggdrasil":               zapcore.InfoLevel,
+		"yggdrasil.remote":        zapcore.WarnLevel,
+		"yggdrasil.remote.grpc":   zapcore.WarnLevel,
+		"yggdrasil.remotes":       zapcore.InfoLevel,
+		"app.billing.invoice.pdf": zapcore.DebugLevel,
+	}
+	for name, want := range cases {
+		if got := lvs.LevelFor(name); got != want {
+			t.Errorf("%q: got %v, want %v", name, got, want)
+		}
+	}
+
+	root.SetLevel(zapcore.ErrorLevel)
+	if got := lvs.LevelFor("yggdrasil"); got != zapcore.ErrorLevel {
+		t.Errorf("root level change not applied: %v", got)
+	}
+	if err := lvs.SetLevelFor("yggdrasil", zapcore.DebugLevel); err != nil {
+		t.Fatal(err)
+	}
+	if got := lvs.LevelFor("yggdrasil.registry"); got != zapcore.DebugLevel {
+		t.Errorf("runtime rule not applied: %v", got)
+	}
+	lvs.UnsetLevelFor("yggdrasil.remote")
+	if got := lvs.LevelFor("yggdrasil.remote.grpc"); got != zapcore.DebugLevel {
+		t.Errorf("removed rule still applied: %v", got)
+	}
+}
+
+func Test_LevelCore(t *testing.T) {
+	root := zap.NewAtomicLevelAt(zapcore.WarnLevel)
+	lvs, _ := newLevels(&root, map[string]string{"app.billing": "debug"})
+	obs, logs := observer.New(zapcore.DebugLevel)
+	lg := zap.New(newLevelCore(sinkCore{obs}, lvs))
+
+	lg.Info("dropped")
+	lg.Named("app").Named("billing").Debug("kept")
+	lg.Named("app").Info("dropped")
+	if logs.Len() != 1 || logs.All()[0].Message != "kept" {
+		t.Fatalf("unexpected entries: %v", logs.All())
+	}
+}
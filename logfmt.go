@@ -0,0 +1,417 @@
+// Copyright 2022 The imkuqin-zw Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zap
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+func init() {
+	RegisterEncoder("logfmt", func(_ *Config, encCfg zapcore.EncoderConfig) (zapcore.Encoder, error) {
+		return NewLogfmtEncoder(encCfg), nil
+	})
+}
+
+var logfmtPool = buffer.NewPool()
+
+// logfmtEncoder writes the entries as logfmt, the nested objects are
+// flattened with dotted keys and the arrays are written as [a,b].
+type logfmtEncoder struct {
+	*zapcore.EncoderConfig
+	buf *buffer.Buffer
+	// prefix is the dotted path of the opened objects and namespaces.
+	prefix string
+}
+
+// NewLogfmtEncoder returns an encoder writing the entries as logfmt.
+func NewLogfmtEncoder(cfg zapcore.EncoderConfig) zapcore.Encoder {
+	return &logfmtEncoder{EncoderConfig: &cfg, buf: logfmtPool.Get()}
+}
+
+func (enc *logfmtEncoder) Clone() zapcore.Encoder {
+	clone := &logfmtEncoder{EncoderConfig: enc.EncoderConfig, buf: logfmtPool.Get(), prefix: enc.prefix}
+	clone.buf.Write(enc.buf.Bytes())
+	return clone
+}
+
+func (enc *logfmtEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	out := logfmtPool.Get()
+	final := &logfmtEncoder{EncoderConfig: enc.EncoderConfig, buf: out}
+	if enc.TimeKey != "" && !ent.Time.IsZero() {
+		final.AddTime(enc.TimeKey, ent.Time)
+	}
+	if enc.LevelKey != "" {
+		encodeLevel := enc.EncodeLevel
+		if encodeLevel == nil {
+			encodeLevel = zapcore.LowercaseLevelEncoder
+		}
+		arr := enc.newArray()
+		encodeLevel(ent.Level, arr)
+		final.addPair(enc.LevelKey, arr.value())
+	}
+	if enc.NameKey != "" && ent.LoggerName != "" {
+		encodeName := enc.EncodeName
+		if encodeName == nil {
+			encodeName = zapcore.FullNameEncoder
+		}
+		arr := enc.newArray()
+		encodeName(ent.LoggerName, arr)
+		final.addPair(enc.NameKey, arr.value())
+	}
+	if ent.Caller.Defined {
+		if enc.CallerKey != "" {
+			encodeCaller := enc.EncodeCaller
+			if encodeCaller == nil {
+				encodeCaller = zapcore.ShortCallerEncoder
+			}
+			arr := enc.newArray()
+			encodeCaller(ent.Caller, arr)
+			final.addPair(enc.CallerKey, arr.value())
+		}
+		if enc.FunctionKey != "" && ent.Caller.Function != "" {
+			final.addPair(enc.FunctionKey, ent.Caller.Function)
+		}
+	}
+	if enc.MessageKey != "" {
+		final.addPair(enc.MessageKey, ent.Message)
+	}
+	if enc.buf.Len() > 0 {
+		if out.Len() > 0 {
+			out.AppendByte(' ')
+		}
+		out.Write(enc.buf.Bytes())
+	}
+	final.prefix = enc.prefix
+	for i := range fields {
+		genericField(fields[i]).AddTo(final)
+	}
+	final.prefix = ""
+	if enc.StacktraceKey != "" && ent.Stack != "" {
+		final.addPair(enc.StacktraceKey, ent.Stack)
+	}
+	if !enc.SkipLineEnding {
+		if enc.LineEnding != "" {
+			out.AppendString(enc.LineEnding)
+		} else {
+			out.AppendString(zapcore.DefaultLineEnding)
+		}
+	}
+	return out, nil
+}
+
+func (enc *logfmtEncoder) addPair(key, value string) {
+	if enc.buf.Len() > 0 {
+		enc.buf.AppendByte(' ')
+	}
+	enc.buf.AppendString(logfmtKey(enc.prefix + key))
+	enc.buf.AppendByte('=')
+	enc.buf.AppendString(logfmtValue(value))
+}
+
+func (enc *logfmtEncoder) newArray() *logfmtArray {
+	return &logfmtArray{cfg: enc.EncoderConfig}
+}
+
+// logfmtKey replaces the characters a key cannot hold.
+func logfmtKey(key string) string {
+	if key == "" {
+		return "_"
+	}
+	return strings.Map(func(r rune) rune {
+		if r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError {
+			return '_'
+		}
+		return r
+	}, key)
+}
+
+// logfmtValue quotes the values holding spaces, quotes, equal signs or
+// control characters.
+func logfmtValue(value string) string {
+	if value == "" {
+		return `""`
+	}
+	for _, r := range value {
+		if r <= ' ' || r == '=' || r == '"' || r == '\\' || r == 0x7f || r == utf8.RuneError {
+			return strconv.Quote(value)
+		}
+	}
+	return value
+}
+
+func (enc *logfmtEncoder) AddArray(key string, marshaler zapcore.ArrayMarshaler) error {
+	arr := enc.newArray()
+	err := marshaler.MarshalLogArray(arr)
+	enc.addPair(key, arr.list())
+	return err
+}
+
+func (enc *logfmtEncoder) AddObject(key string, marshaler zapcore.ObjectMarshaler) error {
+	prefix := enc.prefix
+	enc.prefix = prefix + key + "."
+	err := marshaler.MarshalLogObject(enc)
+	enc.prefix = prefix
+	return err
+}
+
+func (enc *logfmtEncoder) AddBinary(key string, value []byte) {
+	enc.addPair(key, base64.StdEncoding.EncodeToString(value))
+}
+
+func (enc *logfmtEncoder) AddByteString(key string, value []byte) {
+	enc.addPair(key, string(value))
+}
+
+func (enc *logfmtEncoder) AddBool(key string, value bool) {
+	enc.addPair(key, strconv.FormatBool(value))
+}
+
+func (enc *logfmtEncoder) AddComplex128(key string, value complex128) {
+	enc.addPair(key, strconv.FormatComplex(value, 'g', -1, 128))
+}
+
+func (enc *logfmtEncoder) AddComplex64(key string, value complex64) {
+	enc.addPair(key, strconv.FormatComplex(complex128(value), 'g', -1, 64))
+}
+
+func (enc *logfmtEncoder) AddDuration(key string, value time.Duration) {
+	arr := enc.newArray()
+	arr.AppendDuration(value)
+	enc.addPair(key, arr.value())
+}
+
+func (enc *logfmtEncoder) AddFloat64(key string, value float64) {
+	enc.addPair(key, strconv.FormatFloat(value, 'g', -1, 64))
+}
+
+func (enc *logfmtEncoder) AddFloat32(key string, value float32) {
+	enc.addPair(key, strconv.FormatFloat(float64(value), 'g', -1, 32))
+}
+
+func (enc *logfmtEncoder) AddInt(key string, value int) {
+	enc.AddInt64(key, int64(value))
+}
+
+func (enc *logfmtEncoder) AddInt64(key string, value int64) {
+	enc.addPair(key, strconv.FormatInt(value, 10))
+}
+
+func (enc *logfmtEncoder) AddInt32(key string, value int32) {
+	enc.AddInt64(key, int64(value))
+}
+
+func (enc *logfmtEncoder) AddInt16(key string, value int16) {
+	enc.AddInt64(key, int64(value))
+}
+
+func (enc *logfmtEncoder) AddInt8(key string, value int8) {
+	enc.AddInt64(key, int64(value))
+}
+
+func (enc *logfmtEncoder) AddString(key, value string) {
+	enc.addPair(key, value)
+}
+
+func (enc *logfmtEncoder) AddTime(key string, value time.Time) {
+	arr := enc.newArray()
+	arr.AppendTime(value)
+	enc.addPair(key, arr.value())
+}
+
+func (enc *logfmtEncoder) AddUint(key string, value uint) {
+	enc.AddUint64(key, uint64(value))
+}
+
+func (enc *logfmtEncoder) AddUint64(key string, value uint64) {
+	enc.addPair(key, strconv.FormatUint(value, 10))
+}
+
+func (enc *logfmtEncoder) AddUint32(key string, value uint32) {
+	enc.AddUint64(key, uint64(value))
+}
+
+func (enc *logfmtEncoder) AddUint16(key string, value uint16) {
+	enc.AddUint64(key, uint64(value))
+}
+
+func (enc *logfmtEncoder) AddUint8(key string, value uint8) {
+	enc.AddUint64(key, uint64(value))
+}
+
+func (enc *logfmtEncoder) AddUintptr(key string, value uintptr) {
+	enc.AddUint64(key, uint64(value))
+}
+
+// AddReflected flattens the maps, the structs and the encoded JSON like the
+// objects, the other values are written as JSON.
+func (enc *logfmtEncoder) AddReflected(key string, value interface{}) error {
+	if f := genericField(zap.Reflect(key, value)); f.Type != zapcore.ReflectType {
+		f.AddTo(enc)
+		return nil
+	}
+	s, err := reflectedString(value)
+	enc.addPair(key, s)
+	return err
+}
+
+func (enc *logfmtEncoder) OpenNamespace(key string) {
+	enc.prefix += key + "."
+}
+
+func reflectedString(value interface{}) (string, error) {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value), err
+	}
+	return string(b), nil
+}
+
+// logfmtArray collects the elements of an array, it also encodes the single
+// values formatted by the encoders of the config.
+type logfmtArray struct {
+	cfg   *zapcore.EncoderConfig
+	elems []string
+}
+
+func (arr *logfmtArray) value() string {
+	return strings.Join(arr.elems, " ")
+}
+
+func (arr *logfmtArray) list() string {
+	return "[" + strings.Join(arr.elems, ",") + "]"
+}
+
+func (arr *logfmtArray) AppendArray(marshaler zapcore.ArrayMarshaler) error {
+	nested := &logfmtArray{cfg: arr.cfg}
+	err := marshaler.MarshalLogArray(nested)
+	arr.elems = append(arr.elems, nested.list())
+	return err
+}
+
+func (arr *logfmtArray) AppendObject(marshaler zapcore.ObjectMarshaler) error {
+	enc := &logfmtEncoder{EncoderConfig: arr.cfg, buf: logfmtPool.Get()}
+	defer enc.buf.Free()
+	err := marshaler.MarshalLogObject(enc)
+	arr.elems = append(arr.elems, "{"+enc.buf.String()+"}")
+	return err
+}
+
+func (arr *logfmtArray) AppendReflected(value interface{}) error {
+	s, err := reflectedString(value)
+	arr.elems = append(arr.elems, s)
+	return err
+}
+
+func (arr *logfmtArray) AppendBool(value bool) {
+	arr.elems = append(arr.elems, strconv.FormatBool(value))
+}
+
+func (arr *logfmtArray) AppendByteString(value []byte) {
+	arr.elems = append(arr.elems, string(value))
+}
+
+func (arr *logfmtArray) AppendComplex128(value complex128) {
+	arr.elems = append(arr.elems, strconv.FormatComplex(value, 'g', -1, 128))
+}
+
+func (arr *logfmtArray) AppendComplex64(value complex64) {
+	arr.elems = append(arr.elems, strconv.FormatComplex(complex128(value), 'g', -1, 64))
+}
+
+func (arr *logfmtArray) AppendFloat64(value float64) {
+	arr.elems = append(arr.elems, strconv.FormatFloat(value, 'g', -1, 64))
+}
+
+func (arr *logfmtArray) AppendFloat32(value float32) {
+	arr.elems = append(arr.elems, strconv.FormatFloat(float64(value), 'g', -1, 32))
+}
+
+func (arr *logfmtArray) AppendInt(value int) {
+	arr.AppendInt64(int64(value))
+}
+
+func (arr *logfmtArray) AppendInt64(value int64) {
+	arr.elems = append(arr.elems, strconv.FormatInt(value, 10))
+}
+
+func (arr *logfmtArray) AppendInt32(value int32) {
+	arr.AppendInt64(int64(value))
+}
+
+func (arr *logfmtArray) AppendInt16(value int16) {
+	arr.AppendInt64(int64(value))
+}
+
+func (arr *logfmtArray) AppendInt8(value int8) {
+	arr.AppendInt64(int64(value))
+}
+
+func (arr *logfmtArray) AppendString(value string) {
+	arr.elems = append(arr.elems, value)
+}
+
+func (arr *logfmtArray) AppendUint(value uint) {
+	arr.AppendUint64(uint64(value))
+}
+
+func (arr *logfmtArray) AppendUint64(value uint64) {
+	arr.elems = append(arr.elems, strconv.FormatUint(value, 10))
+}
+
+func (arr *logfmtArray) AppendUint32(value uint32) {
+	arr.AppendUint64(uint64(value))
+}
+
+func (arr *logfmtArray) AppendUint16(value uint16) {
+	arr.AppendUint64(uint64(value))
+}
+
+func (arr *logfmtArray) AppendUint8(value uint8) {
+	arr.AppendUint64(uint64(value))
+}
+
+func (arr *logfmtArray) AppendUintptr(value uintptr) {
+	arr.AppendUint64(uint64(value))
+}
+
+func (arr *logfmtArray) AppendDuration(value time.Duration) {
+	n := len(arr.elems)
+	if arr.cfg.EncodeDuration != nil {
+		arr.cfg.EncodeDuration(value, arr)
+	}
+	if len(arr.elems) == n {
+		arr.elems = append(arr.elems, value.String())
+	}
+}
+
+func (arr *logfmtArray) AppendTime(value time.Time) {
+	n := len(arr.elems)
+	if arr.cfg.EncodeTime != nil {
+		arr.cfg.EncodeTime(value, arr)
+	}
+	if len(arr.elems) == n {
+		arr.elems = append(arr.elems, value.Format(time.RFC3339Nano))
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2022 The imkuqin-zw Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zap
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/imkuqin-zw/yggdrasil/pkg/logger"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type logfmtUser struct {
+	Name string
+	Tags []string
+}
+
+func (u logfmtUser) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("name", u.Name)
+	return enc.AddArray("tags", zapcore.ArrayMarshalerFunc(func(arr zapcore.ArrayEncoder) error {
+		for _, tag := range u.Tags {
+			arr.AppendString(tag)
+		}
+		return nil
+	}))
+}
+
+func Test_LogfmtEncoder(t *testing.T) {
+	ent := zapcore.Entry{
+		Level:      zapcore.WarnLevel,
+		Time:       time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC),
+		LoggerName: "app",
+		Message:    "say \"hi\"",
+		Caller:     zapcore.NewEntryCaller(0, "/src/pkg/main.go", 42, true),
+	}
+	enc := NewLogfmtEncoder(zapcore.EncoderConfig{
+		TimeKey:     "ts",
+		LevelKey:    "level",
+		NameKey:     "logger",
+		CallerKey:   "caller",
+		MessageKey:  "msg",
+		EncodeTime:  zapcore.ISO8601TimeEncoder,
+		EncodeLevel: zapcore.CapitalLevelEncoder,
+	})
+	enc.AddString("ctx", "a b")
+	fields := []zapcore.Field{
+		zap.Object("user", logfmtUser{Name: "bob", Tags: []string{"x", "y"}}),
+		zap.Ints("ids", []int{1, 2}),
+		zap.String("empty", ""),
+		zap.String("bad key", "a=b"),
+		zap.Namespace("req"),
+		zap.Duration("took", time.Second),
+	}
+	buf, err := enc.EncodeEntry(ent, fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `ts=2022-01-02T03:04:05.000Z level=WARN logger=app caller=pkg/main.go:42 msg="say \"hi\"" ` +
+		`ctx="a b" user.name=bob user.tags=[x,y] ids=[1,2] empty="" bad_key="a=b" req.took=1s` + "\n"
+	if got := buf.String(); got != want {
+		t.Fatalf("\ngot:  %s\nwant: %s", got, want)
+	}
+
+	buf, err = NewLogfmtEncoder(zapcore.EncoderConfig{MessageKey: "msg", SkipLineEnding: true}).EncodeEntry(
+		zapcore.Entry{Message: "m"}, []zapcore.Field{zap.Objects("users", []logfmtUser{{Name: "a"}})})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != `msg=m users="[{name=a tags=[]}]"` {
+		t.Fatalf("unexpected output: %s", got)
+	}
+}
+
+func Test_LogfmtReflected(t *testing.T) {
+	cfg := &Config{}
+	cfg.Encoding.File = "logfmt"
+	lg, lines := newTestLogger(t, cfg)
+	logger.NewLogger(logger.LvDebug, lg).InfoField("ext", logger.String("user", "u1"), logger.Int("n", 1))
+	lg.Write(logger.LvInfo, "kvs", "obj", map[string]interface{}{"a": map[string]int{"b": 1}}, "ids", []int{1, 2})
+
+	got := lines()
+	if len(got) != 2 {
+		t.Fatalf("unexpected lines: %v", got)
+	}
+	for i, want := range []string{" msg=ext ext.n=1 ext.user=u1", " msg=kvs obj.a.b=1 ids=[1,2]"} {
+		if !strings.HasSuffix(got[i], want) {
+			t.Errorf("unexpected line: %s", got[i])
+		}
+	}
+}
@@ -15,13 +15,14 @@
 package zap
 
 import (
-	"os"
 	"sync"
 
 	"github.com/imkuqin-zw/yggdrasil/pkg/config"
 	"github.com/imkuqin-zw/yggdrasil/pkg/logger"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc/grpclog"
+	"gopkg.in/natefinch/lumberjack.v2"
 )
 
 func init() {
@@ -30,12 +31,30 @@ func init() {
 		if err := config.Get("zap").Scan(cfg); err != nil {
 			logger.FatalField("fault to load zap config", logger.Err(err))
 		}
-		return cfg.Build()
+		built.mu.Lock()
+		defer built.mu.Unlock()
+		if built.lg != nil {
+			built.lg.Close()
+		}
+		lg := cfg.Build()
+		if cfg.GrpcLog.Enable {
+			grpclog.SetLoggerV2(NewGrpcLogger(lg, &cfg.GrpcLog.GrpcLogConfig))
+		}
+		built.lg = lg
+		return lg
 	})
 }
 
+// built is the logger of the last writer built for yggdrasil, it is closed
+// when the writer is built again.
+var built struct {
+	mu sync.Mutex
+	lg *Logger
+}
+
 var (
 	mu              sync.Mutex
+	fileLogger      *lumberjack.Logger
 	fileWriteSyncer zapcore.WriteSyncer
 )
 
@@ -43,51 +62,174 @@ func getWriteSyncer(cfg *Config) zapcore.WriteSyncer {
 	mu.Lock()
 	defer mu.Unlock()
 	if fileWriteSyncer == nil {
-		fileWriteSyncer = newFileSyncer(&cfg.File.FileConfig)
+		fileLogger = newFileLogger(&cfg.File.FileConfig)
+		fileWriteSyncer = zapcore.AddSync(fileLogger)
 	}
 	return fileWriteSyncer
 }
 
+// reopenFile closes the file sink, the next write opens the file again so
+// the file created by an external rotation is picked up.
+func reopenFile() error {
+	mu.Lock()
+	defer mu.Unlock()
+	if fileLogger == nil {
+		return nil
+	}
+	return fileLogger.Close()
+}
+
 type Logger struct {
-	cfg   *Config
-	sugar *zap.SugaredLogger
-	lv    *zap.AtomicLevel
+	cfg  *Config
+	name string
+	// core is the ungated chain of the sinks, shared with the clones.
+	core        zapcore.Core
+	rec         *flightRecorder
+	owner       bool
+	sugar       *zap.SugaredLogger
+	forced      *zap.SugaredLogger
+	lv          *zap.AtomicLevel
+	levels      *levels
+	stopSignals func()
+	stopCapture func()
+
+	restoreGlobals func()
 }
 
 func (lg *Logger) Write(lv logger.Level, msg string, kvs ...interface{}) {
+	lg.write(lg.sugar, lv, msg, kvs)
+}
+
+func (lg *Logger) write(sugar *zap.SugaredLogger, lv logger.Level, msg string, kvs []interface{}) {
 	switch lv {
 	case logger.LvDebug:
-		lg.sugar.Debugw(msg, kvs...)
+		sugar.Debugw(msg, kvs...)
 	case logger.LvInfo:
-		lg.sugar.Infow(msg, kvs...)
+		sugar.Infow(msg, kvs...)
 	case logger.LvWarn:
-		lg.sugar.Warnw(msg, kvs...)
+		sugar.Warnw(msg, kvs...)
 	case logger.LvError:
-		lg.sugar.Errorw(msg, kvs...)
+		sugar.Errorw(msg, kvs...)
 	case logger.LvFault:
-		lg.sugar.Fatalw(msg, kvs...)
+		sugar.Fatalw(msg, kvs...)
 	}
 }
 
-var _ logger.Writer = (*Logger)(nil)
+// Named adds a sub-scope to the name of the logger, the named loggers share
+// the levels of their parent.
+func (lg *Logger) Named(name string) *Logger {
+	if name == "" {
+		return lg
+	}
+	l := *lg
+	l.owner = false
+	l.sugar = lg.sugar.Named(name)
+	l.forced = lg.forced.Named(name)
+	if lg.name == "" {
+		l.name = name
+	} else {
+		l.name = lg.name + "." + name
+	}
+	register(&l)
+	return &l
+}
 
-func newLogger(lv *zap.AtomicLevel, cfg *Config) *Logger {
-	zapOptions := make([]zap.Option, 0)
-	zapOptions = append(zapOptions, zap.AddStacktrace(zap.PanicLevel))
-	if cfg.AddCaller {
-		zapOptions = append(zapOptions, zap.AddCaller(), zap.AddCallerSkip(cfg.CallerSkip))
+// Clone creates a logger writing to the same sinks whose levels are isolated
+// from the ones of lg.
+func (lg *Logger) Clone() *Logger {
+	lv := zap.NewAtomicLevelAt(lg.lv.Level())
+	l := newLogger(&lv, lg.cfg, lg.core, lg.rec)
+	if lg.name != "" {
+		l.sugar = l.sugar.Named(lg.name)
+		l.forced = l.forced.Named(lg.name)
+		l.name = lg.name
 	}
+	register(l)
+	return l
+}
+
+// Close unregisters the logger from the admin handler, the named loggers of
+// a root or cloned logger are unregistered with it. Closing the logger
+// returned by NewLogger also stops the signal handlers and the capture, and
+// restores the globals.
+func (lg *Logger) Close() {
+	unregister(lg)
+	if !lg.owner {
+		return
+	}
+	if lg.stopSignals != nil {
+		lg.stopSignals()
+		lg.stopSignals = nil
+	}
+	lg.StopCapture()
+	lg.RestoreGlobals()
+}
+
+// Level returns the effective level of the logger.
+func (lg *Logger) Level() zapcore.Level {
+	return lg.levels.LevelFor(lg.name)
+}
+
+// SetLevel sets the root level, or the level rule of the name of a named
+// logger. A pending elevation of the logger is cancelled.
+func (lg *Logger) SetLevel(lv zapcore.Level) {
+	lg.cancelElevation()
+	lg.setLevel(lv)
+}
+
+// setLevel sets the level and returns the function restoring the previous one.
+func (lg *Logger) setLevel(lv zapcore.Level) func() {
+	if lg.name == "" {
+		prev := lg.lv.Level()
+		lg.lv.SetLevel(lv)
+		return func() { lg.lv.SetLevel(prev) }
+	}
+	prev, ok := lg.levels.Rules()[lg.name]
+	_ = lg.levels.SetLevelFor(lg.name, lv)
+	return func() {
+		if ok {
+			_ = lg.levels.SetLevelFor(lg.name, prev)
+		} else {
+			lg.levels.UnsetLevelFor(lg.name)
+		}
+	}
+}
+
+// SetLevelFor sets the level of the loggers whose name is name or starts
+// with name followed by a dot.
+func (lg *Logger) SetLevelFor(name string, lv zapcore.Level) error {
+	return lg.levels.SetLevelFor(name, lv)
+}
+
+// UnsetLevelFor removes the level rule of name.
+func (lg *Logger) UnsetLevelFor(name string) {
+	lg.levels.UnsetLevelFor(name)
+}
+
+// LevelFor returns the effective level of the named logger.
+func (lg *Logger) LevelFor(name string) zapcore.Level {
+	return lg.levels.LevelFor(name)
+}
+
+var _ logger.Writer = (*Logger)(nil)
+
+// newSinks builds the ungated chain of the sinks and the flight recorder.
+func newSinks(cfg *Config) (zapcore.Core, *flightRecorder) {
+	stdout, stderr := stdio(cfg)
 	cores := make([]zapcore.Core, 0, 1)
 	isErr := zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
-		return lvl >= zapcore.ErrorLevel && lv.Level() <= zapcore.ErrorLevel
+		return lvl >= zapcore.ErrorLevel
 	})
 	isNotErr := zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
-		return lvl < zapcore.ErrorLevel && lv.Level() <= lvl
+		return lvl < zapcore.ErrorLevel
 	})
 
+	// reserved are the top-level keys written by the encoders.
+	reserved := map[string]bool{}
 	if cfg.Console.Enable {
-		var wsOut, wsErr = zapcore.Lock(os.Stdout), zapcore.Lock(os.Stderr)
-		var encoder = zapcore.NewConsoleEncoder(*cfg.Console.Encoder)
+		var wsOut, wsErr = zapcore.Lock(stdout), zapcore.Lock(stderr)
+		var encoder = newEncoder(cfg, cfg.Encoding.Console, "console", *cfg.Console.Encoder)
+		addEncoderKeys(reserved, encoder, cfg.Console.Encoder)
 		cores = append(cores,
 			zapcore.NewCore(encoder, wsErr, isErr),
 			zapcore.NewCore(encoder, wsOut, isNotErr),
@@ -95,16 +237,108 @@ func newLogger(lv *zap.AtomicLevel, cfg *Config) *Logger {
 	}
 	if cfg.File.Enable {
 		ws := zapcore.AddSync(getWriteSyncer(cfg))
-		encoder := zapcore.NewJSONEncoder(*cfg.File.Encoder)
-		cores = append(cores, zapcore.NewCore(encoder, ws, lv))
+		encoder := newEncoder(cfg, cfg.Encoding.File, "json", *cfg.File.Encoder)
+		addEncoderKeys(reserved, encoder, cfg.File.Encoder)
+		cores = append(cores, zapcore.NewCore(encoder, ws, anyLevel))
 	}
-	lg := zap.New(zapcore.NewTee(cores...), zapOptions...)
-	l := &Logger{
-		cfg:   cfg,
-		sugar: lg.Sugar(),
-		lv:    lv,
+	if cfg.Gelf.Enable {
+		ws := newGelfSyncer(&cfg.Gelf.GelfConfig)
+		encoder := NewGelfEncoder(*cfg.Gelf.Encoder, cfg.Gelf.Host)
+		addEncoderKeys(reserved, encoder, cfg.Gelf.Encoder)
+		cores = append(cores, zapcore.NewCore(encoder, ws, anyLevel))
+	}
+	if cfg.Journald.Enable {
+		cores = append(cores, newJournaldCore(&cfg.Journald.JournaldConfig, anyLevel))
+	}
+	var core zapcore.Core = sinkCore(cores)
+	if cfg.Static.Enable {
+		// The static fields are encoded once by the sinks.
+		core = core.With(cfg.Static.staticFields())
+	}
+	if cfg.Kubernetes.Enable {
+		core = core.With(cfg.Kubernetes.kubernetesFields())
+	}
+	if cfg.Keys.Enable {
+		// The keys are rewritten after the redaction matched them.
+		for _, key := range cfg.Keys.Reserved {
+			reserved[key] = true
+		}
+		k, err := newKeyRewriter(&cfg.Keys.KeysConfig, reserved)
+		if err != nil {
+			panic(err)
+		}
+		core = newKeysCore(core, k)
+	}
+	if cfg.Redact.Enable {
+		r, err := newRedactor(&cfg.Redact.RedactConfig)
+		if err != nil {
+			panic(err)
+		}
+		core = newFieldsCore(core, r.rewrite)
+	}
+	if cfg.Errors.Enable {
+		// The errors are expanded before the redaction applies to them.
+		core = newFieldsCore(core, cfg.Errors.expandErrors)
+	}
+	var rec *flightRecorder
+	if cfg.FlightRecorder.Enable {
+		var err error
+		if rec, err = newFlightRecorder(&cfg.FlightRecorder.FlightRecorderConfig); err != nil {
+			panic(err)
+		}
+	}
+	return core, rec
+}
+
+func newLogger(lv *zap.AtomicLevel, cfg *Config, core zapcore.Core, rec *flightRecorder) *Logger {
+	zapOptions := make([]zap.Option, 0)
+	if !cfg.Stacktrace.Enable {
+		zapOptions = append(zapOptions, zap.AddStacktrace(zap.PanicLevel))
+	}
+	// The captured descriptors must not receive the internal errors of zap.
+	_, stderr := stdio(cfg)
+	zapOptions = append(zapOptions, zap.ErrorOutput(zapcore.Lock(stderr)))
+	if cfg.AddCaller && !cfg.Caller.Auto {
+		// Skip the write helper shared by Write and WriteContext.
+		zapOptions = append(zapOptions, zap.AddCaller(), zap.AddCallerSkip(cfg.CallerSkip+1))
+	}
+	lvs, err := newLevels(lv, cfg.Levels)
+	if err != nil {
+		panic(err)
+	}
+	ungated := core
+	// The stack and the caller are looked up when the entries are checked,
+	// the cores capturing them wrap the gating cores.
+	packages := cfg.loggerPackages()
+	wrap := func(core zapcore.Core) zapcore.Core {
+		if cfg.Stacktrace.Enable {
+			var err error
+			if core, err = newStackCore(core, &cfg.Stacktrace.StacktraceConfig, packages); err != nil {
+				panic(err)
+			}
+		}
+		if cfg.AddCaller && cfg.Caller.Auto {
+			core = newCallerCore(core, packages)
+		}
+		return core
+	}
+	forced := zap.New(wrap(core), zapOptions...)
+	if rec != nil {
+		core = newRecorderCore(core, lvs, rec)
+	} else {
+		core = newLevelCore(core, lvs)
+	}
+	lg := zap.New(wrap(core), zapOptions...)
+	return &Logger{
+		cfg:    cfg,
+		core:   ungated,
+		rec:    rec,
+		owner:  true,
+		sugar:  lg.Sugar(),
+		forced: forced.Sugar(),
+		lv:     lv,
+		levels: lvs,
 	}
-	return l
 }
 
 func NewLogger(cfg *Config) *Logger {
@@ -112,6 +346,14 @@ func NewLogger(cfg *Config) *Logger {
 	if err := lv.UnmarshalText([]byte(cfg.Level)); err != nil {
 		panic(err)
 	}
-	lg := newLogger(&lv, cfg)
+	core, rec := newSinks(cfg)
+	lg := newLogger(&lv, cfg, core, rec)
+	register(lg)
+	if cfg.Admin.Enable {
+		serveAdmin(cfg.Admin.Path)
+	}
+	lg.stopSignals = lg.watchSignals()
+	lg.restoreGlobals = lg.redirectGlobals()
+	lg.stopCapture = lg.captureStdio()
 	return lg
 }
@@ -15,6 +15,10 @@
 package zap
 
 import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/imkuqin-zw/yggdrasil/pkg/logger"
@@ -38,3 +42,43 @@ func Test_Logger(t *testing.T) {
 	//h := logger.WithFields(logger.String("plugins", "zap"))
 	//h.DebugField("fdafdsaf", logger.String("k1", "k2"))
 }
+
+func resetFileSink() {
+	mu.Lock()
+	defer mu.Unlock()
+	if fileLogger != nil {
+		_ = fileLogger.Close()
+	}
+	fileLogger, fileWriteSyncer = nil, nil
+}
+
+// newTestLogger builds the logger of cfg with a file sink in a temporary
+// directory, the returned function reads the lines written so far.
+func newTestLogger(t *testing.T, cfg *Config) (*Logger, func() []string) {
+	t.Helper()
+	resetFileSink()
+	t.Cleanup(resetFileSink)
+	cfg.File.Enable = true
+	cfg.File.Dir = t.TempDir()
+	lg := cfg.Build()
+	path := filepath.Join(cfg.File.Dir, defaultFileName)
+	return lg, func() []string {
+		b, err := os.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			t.Fatal(err)
+		}
+		if len(b) == 0 {
+			return nil
+		}
+		return strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+	}
+}
+
+func decodeLine(t *testing.T, line string) map[string]interface{} {
+	t.Helper()
+	var m map[string]interface{}
+	if err := json.Unmarshal([]byte(line), &m); err != nil {
+		t.Fatalf("%v: %s", err, line)
+	}
+	return m
+}
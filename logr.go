@@ -0,0 +1,97 @@
+// Copyright 2022 The imkuqin-zw Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zap
+
+import (
+	"fmt"
+
+	"github.com/go-logr/logr"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type logSink struct {
+	sugar *zap.SugaredLogger
+}
+
+// NewLogSink returns a logr.LogSink writing through the sinks and the levels
+// of lg. As in zapr, V(n) is mapped to the level -n, so V(1) is the debug
+// level and the higher verbosities need a level set below it.
+func NewLogSink(lg *Logger) logr.LogSink {
+	return &logSink{sugar: lg.Zap().WithOptions(zap.AddCallerSkip(1)).Sugar()}
+}
+
+// NewLogr returns a logr.Logger backed by lg, e.g. for otel.SetLogger.
+func NewLogr(lg *Logger) logr.Logger {
+	return logr.New(NewLogSink(lg))
+}
+
+func (s *logSink) Init(info logr.RuntimeInfo) {
+	// logr calls the sink through info.CallDepth frames.
+	s.sugar = s.sugar.Desugar().WithOptions(zap.AddCallerSkip(info.CallDepth)).Sugar()
+}
+
+func (s *logSink) Enabled(level int) bool {
+	return s.sugar.Desugar().Core().Enabled(logrLevel(level))
+}
+
+func (s *logSink) Info(level int, msg string, kvs ...interface{}) {
+	if ce := s.sugar.Desugar().Check(logrLevel(level), msg); ce != nil {
+		ce.Write(logrFields(kvs)...)
+	}
+}
+
+func (s *logSink) Error(err error, msg string, kvs ...interface{}) {
+	s.sugar.Errorw(msg, append([]interface{}{zap.Error(err)}, kvs...)...)
+}
+
+func (s *logSink) WithValues(kvs ...interface{}) logr.LogSink {
+	return &logSink{sugar: s.sugar.With(kvs...)}
+}
+
+func (s *logSink) WithName(name string) logr.LogSink {
+	return &logSink{sugar: s.sugar.Named(name)}
+}
+
+func (s *logSink) WithCallDepth(depth int) logr.LogSink {
+	return &logSink{sugar: s.sugar.Desugar().WithOptions(zap.AddCallerSkip(depth)).Sugar()}
+}
+
+// logrFields converts the key-value pairs of logr to fields like zapr, the
+// zap fields are kept as is.
+func logrFields(kvs []interface{}) []zap.Field {
+	fields := make([]zap.Field, 0, len(kvs)/2+1)
+	for i := 0; i < len(kvs); i++ {
+		if f, ok := kvs[i].(zap.Field); ok {
+			fields = append(fields, f)
+			continue
+		}
+		if i+1 == len(kvs) {
+			fields = append(fields, zap.Any("ignored key", kvs[i]))
+			break
+		}
+		key, ok := kvs[i].(string)
+		if !ok {
+			key = fmt.Sprint(kvs[i])
+		}
+		fields = append(fields, zap.Any(key, kvs[i+1]))
+		i++
+	}
+	return fields
+}
+
+func logrLevel(level int) zapcore.Level {
+	return zapcore.Level(-level)
+}
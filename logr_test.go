@@ -0,0 +1,65 @@
+// Copyright 2022 The imkuqin-zw Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zap
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func Test_LogSink(t *testing.T) {
+	lg, lines := newTestLogger(t, &Config{AddCaller: true})
+	lg.SetLevel(zapcore.InfoLevel)
+	before := registered()
+
+	lr := NewLogr(lg).WithName("otel").WithValues("a", 1)
+	lr.V(1).Info("dropped")
+	lr.Info("info", "c", 3, zap.String("z", "y"), "dangling")
+	lr.Error(errors.New("boom"), "failed", "b", 2)
+	if err := lg.SetLevelFor("otel", zapcore.Level(-2)); err != nil {
+		t.Fatal(err)
+	}
+	lr.V(1).Info("debug")
+	lr.V(2).Info("trace")
+	lr.V(3).Info("dropped")
+	if n := registered(); n != before {
+		t.Errorf("WithName registered %d admin loggers", n-before)
+	}
+
+	entries := lines()
+	if len(entries) != 4 {
+		t.Fatalf("unexpected entries: %q", entries)
+	}
+	for i, want := range []string{"info", "error", "debug", "Level(-2)"} {
+		got := decodeLine(t, entries[i])
+		if got["lv"] != want || got["Logger"] != "otel" || got["a"] != float64(1) {
+			t.Errorf("unexpected entry %d: %v", i, got)
+		}
+		if caller, _ := got["caller"].(string); !strings.HasPrefix(filepath.Base(caller), "logr_test.go:") {
+			t.Errorf("unexpected caller %d: %s", i, caller)
+		}
+	}
+	if got := decodeLine(t, entries[0]); got["c"] != float64(3) || got["z"] != "y" || got["ignored key"] != "dangling" {
+		t.Errorf("unexpected info fields: %v", got)
+	}
+	if got := decodeLine(t, entries[1]); got["error"] != "boom" || got["b"] != float64(2) {
+		t.Errorf("unexpected error fields: %v", got)
+	}
+}
@@ -0,0 +1,170 @@
+// Copyright 2022 The imkuqin-zw Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zap
+
+import (
+	"encoding/json"
+	"sync"
+
+	"go.uber.org/multierr"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	defaultRecorderSize = 256
+
+	defaultRecorderLevel = "debug"
+
+	defaultRecorderTriggerLevel = "error"
+)
+
+type FlightRecorderConfig struct {
+	// Size is the number of entries kept in memory.
+	Size int
+	// Level is the minimum level of the recorded entries.
+	Level string
+	// TriggerLevel is the level of the entries dumping the recorded ones.
+	TriggerLevel string
+}
+
+type recordedEntry struct {
+	// core is the sinks with the context of the logger that recorded the entry.
+	core   zapcore.Core
+	ent    zapcore.Entry
+	fields []zapcore.Field
+}
+
+// flightRecorder is a ring of the entries dropped by the level of the logger.
+type flightRecorder struct {
+	mu      sync.Mutex
+	entries []recordedEntry
+	next    int
+	size    int
+	level   zapcore.Level
+	trigger zapcore.Level
+}
+
+func newFlightRecorder(config *FlightRecorderConfig) (*flightRecorder, error) {
+	if config.Size <= 0 {
+		config.Size = defaultRecorderSize
+	}
+	if config.Level == "" {
+		config.Level = defaultRecorderLevel
+	}
+	if config.TriggerLevel == "" {
+		config.TriggerLevel = defaultRecorderTriggerLevel
+	}
+	rec := &flightRecorder{entries: make([]recordedEntry, config.Size)}
+	if err := rec.level.UnmarshalText([]byte(config.Level)); err != nil {
+		return nil, err
+	}
+	if err := rec.trigger.UnmarshalText([]byte(config.TriggerLevel)); err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
+
+func (rec *flightRecorder) record(core zapcore.Core, ent zapcore.Entry, fields []zapcore.Field) {
+	fs := make([]zapcore.Field, len(fields))
+	for i, f := range fields {
+		fs[i] = ownField(f)
+	}
+	rec.mu.Lock()
+	rec.entries[rec.next] = recordedEntry{core: core, ent: ent, fields: fs}
+	rec.next = (rec.next + 1) % len(rec.entries)
+	if rec.size < len(rec.entries) {
+		rec.size++
+	}
+	rec.mu.Unlock()
+}
+
+// ownField copies the bytes held by f, the callers may reuse them once the
+// entry is written, e.g. yggdrasil frees the buffer of its raw JSON fields.
+func ownField(f zapcore.Field) zapcore.Field {
+	if raw, ok := rawJSON(f); ok {
+		f.Interface = json.RawMessage(append([]byte(nil), raw...))
+		return f
+	}
+	switch f.Type {
+	case zapcore.ByteStringType, zapcore.BinaryType:
+		if b, ok := f.Interface.([]byte); ok {
+			f.Interface = append([]byte(nil), b...)
+		}
+	}
+	return f
+}
+
+// dump writes the recorded entries from the oldest one and forgets them, so
+// the same context is never dumped twice.
+func (rec *flightRecorder) dump() error {
+	rec.mu.Lock()
+	entries := make([]recordedEntry, 0, rec.size)
+	start := rec.next - rec.size
+	if start < 0 {
+		start += len(rec.entries)
+	}
+	for i := 0; i < rec.size; i++ {
+		idx := (start + i) % len(rec.entries)
+		entries = append(entries, rec.entries[idx])
+		rec.entries[idx] = recordedEntry{}
+	}
+	rec.size = 0
+	rec.mu.Unlock()
+
+	var err error
+	for _, item := range entries {
+		err = multierr.Append(err, item.core.Write(item.ent, item.fields))
+	}
+	return err
+}
+
+// recorderCore gates the sinks like levelCore, but keeps the dropped entries
+// in the flight recorder instead of discarding them.
+type recorderCore struct {
+	zapcore.Core
+	lvs *levels
+	rec *flightRecorder
+}
+
+func newRecorderCore(core zapcore.Core, lvs *levels, rec *flightRecorder) zapcore.Core {
+	return &recorderCore{Core: core, lvs: lvs, rec: rec}
+}
+
+func (c *recorderCore) Enabled(lvl zapcore.Level) bool {
+	return c.lvs.Enabled(lvl) || lvl >= c.rec.level
+}
+
+func (c *recorderCore) With(fields []zapcore.Field) zapcore.Core {
+	return &recorderCore{Core: c.Core.With(fields), lvs: c.lvs, rec: c.rec}
+}
+
+func (c *recorderCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if ent.Level >= c.rec.level || c.lvs.EnabledFor(ent.LoggerName, ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *recorderCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if !c.lvs.EnabledFor(ent.LoggerName, ent.Level) {
+		c.rec.record(c.Core, ent, fields)
+		return nil
+	}
+	var err error
+	if ent.Level >= c.rec.trigger {
+		err = c.rec.dump()
+	}
+	return multierr.Append(err, c.Core.Write(ent, fields))
+}
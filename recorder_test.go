@@ -0,0 +1,91 @@
+// Copyright 2022 The imkuqin-zw Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zap
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/imkuqin-zw/yggdrasil/pkg/logger"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func Test_FlightRecorder(t *testing.T) {
+	rec, err := newFlightRecorder(&FlightRecorderConfig{Size: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lv := zap.NewAtomicLevelAt(zapcore.InfoLevel)
+	lvs, _ := newLevels(&lv, nil)
+	obs, logs := observer.New(zapcore.DebugLevel)
+	lg := zap.New(newRecorderCore(sinkCore{obs}, lvs, rec))
+
+	lg.Debug("d1")
+	lg.With(zap.String("k", "v")).Debug("d2")
+	lg.Debug("d3")
+	lg.Info("i1")
+	lg.Error("e1")
+	lg.Error("e2")
+
+	var got []string
+	for _, item := range logs.AllUntimed() {
+		got = append(got, item.Message)
+	}
+	want := []string{"i1", "d2", "d3", "e1", "e2"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if ctx := logs.AllUntimed()[1].ContextMap(); ctx["k"] != "v" {
+		t.Fatalf("recorded entry lost its context: %v", ctx)
+	}
+}
+
+func Test_FlightRecorderOwnsFields(t *testing.T) {
+	cfg := &Config{}
+	cfg.FlightRecorder.Enable = true
+	lg, lines := newTestLogger(t, cfg)
+	lg.SetLevel(zapcore.InfoLevel)
+	yl := logger.NewLogger(logger.LvDebug, lg)
+
+	yl.DebugField("d1", logger.String("secret", strings.Repeat("A", 32)))
+	yl.DebugField("d2", logger.String("x", "y"))
+	yl.ErrorField("boom", logger.String("other", strings.Repeat("B", 32)))
+
+	want := map[string]string{
+		"d1":   `{"secret":"` + strings.Repeat("A", 32) + `"}`,
+		"d2":   `{"x":"y"}`,
+		"boom": `{"other":"` + strings.Repeat("B", 32) + `"}`,
+	}
+	entries := lines()
+	if len(entries) != len(want) {
+		t.Fatalf("unexpected entries: %q", entries)
+	}
+	for _, line := range entries {
+		got := decodeLine(t, line)
+		msg, _ := got["msg"].(string)
+		ext, ok := got["ext"].(string)
+		if !ok {
+			b, _ := json.Marshal(got["ext"])
+			ext = string(b)
+		}
+		if ext != want[msg] {
+			t.Errorf("%s: got ext %s, want %s", msg, ext, want[msg])
+		}
+	}
+}
@@ -0,0 +1,470 @@
+// Copyright 2022 The imkuqin-zw Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zap
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	redactMask = "mask"
+	redactHash = "hash"
+	redactDrop = "drop"
+
+	defaultRedactMask = "***"
+
+	defaultRedactTagName = "redact"
+)
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+type RedactRule struct {
+	// Keys are the field keys the rule applies to, glob patterns are
+	// supported and the match is case-insensitive.
+	Keys []string
+	// Pattern is a regular expression applied to string values. Without a
+	// pattern the whole value of the matched keys is redacted.
+	Pattern string
+	// Action is one of mask, hash or drop.
+	Action string
+}
+
+type RedactConfig struct {
+	Rules []RedactRule
+	// HashKey is the HMAC key of the hash action, it is required by the hash
+	// rules and the fields tagged with hash are masked without it.
+	HashKey string
+	// Mask replaces the redacted values of the mask action.
+	Mask string
+	// TagName is the struct tag marking the fields of logged objects,
+	// e.g. `redact:"mask"`.
+	TagName string
+}
+
+type redactRule struct {
+	keys    []string
+	pattern *regexp.Regexp
+	action  string
+}
+
+func (rule *redactRule) matchKey(key string) bool {
+	if len(rule.keys) == 0 {
+		return rule.pattern != nil
+	}
+	key = strings.ToLower(key)
+	for _, item := range rule.keys {
+		if ok, _ := path.Match(item, key); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// redactor masks, hashes or drops the values matched by the rules before
+// they reach any encoder.
+type redactor struct {
+	rules   []redactRule
+	hashKey []byte
+	mask    string
+	tagName string
+}
+
+func newRedactor(config *RedactConfig) (*redactor, error) {
+	if config.Mask == "" {
+		config.Mask = defaultRedactMask
+	}
+	if config.TagName == "" {
+		config.TagName = defaultRedactTagName
+	}
+	r := &redactor{hashKey: []byte(config.HashKey), mask: config.Mask, tagName: config.TagName}
+	for _, item := range config.Rules {
+		if !validRedactAction(item.Action) {
+			return nil, fmt.Errorf("unknown redact action: %q", item.Action)
+		}
+		if item.Action == redactHash && config.HashKey == "" {
+			return nil, errors.New("redact hash rule requires a hash key")
+		}
+		rule := redactRule{action: item.Action}
+		for _, key := range item.Keys {
+			rule.keys = append(rule.keys, strings.ToLower(key))
+		}
+		if item.Pattern != "" {
+			pattern, err := regexp.Compile(item.Pattern)
+			if err != nil {
+				return nil, err
+			}
+			rule.pattern = pattern
+		}
+		if rule.pattern == nil && len(rule.keys) == 0 {
+			return nil, errors.New("redact rule requires keys or a pattern")
+		}
+		r.rules = append(r.rules, rule)
+	}
+	return r, nil
+}
+
+func validRedactAction(action string) bool {
+	switch action {
+	case redactMask, redactHash, redactDrop:
+		return true
+	}
+	return false
+}
+
+func (r *redactor) rewrite(fields []zapcore.Field) []zapcore.Field {
+	out := make([]zapcore.Field, 0, len(fields))
+	for _, f := range fields {
+		if f, keep := r.field(f); keep {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+func (r *redactor) field(f zapcore.Field) (zapcore.Field, bool) {
+	if raw, ok := rawJSON(f); ok {
+		return r.raw(f, raw)
+	}
+	switch f.Type {
+	case zapcore.NamespaceType, zapcore.SkipType, zapcore.InlineMarshalerType:
+		return f, true
+	case zapcore.StringType:
+		out, keep, changed := r.redact(f.Key, f.String)
+		if !changed {
+			return f, true
+		}
+		return zap.Any(f.Key, out), keep
+	case zapcore.ErrorType:
+		msg, ok := errorMessage(f.Interface.(error))
+		if !ok {
+			return f, true
+		}
+		out, keep, changed := r.redact(f.Key, msg)
+		if !changed {
+			return f, true
+		}
+		if s, ok := out.(string); ok {
+			return zap.NamedError(f.Key, errors.New(s)), keep
+		}
+		return zap.Any(f.Key, out), keep
+	case zapcore.StringerType:
+		s, ok := safeString(f.Interface.(fmt.Stringer))
+		if !ok {
+			return f, true
+		}
+		out, keep, changed := r.redact(f.Key, s)
+		if !changed {
+			return f, true
+		}
+		return zap.Any(f.Key, out), keep
+	case zapcore.ReflectType:
+		return r.reflected(f)
+	case zapcore.ArrayMarshalerType, zapcore.ObjectMarshalerType:
+		enc := zapcore.NewMapObjectEncoder()
+		f.AddTo(enc)
+		out, keep, changed := r.redact(f.Key, enc.Fields[f.Key])
+		if !changed {
+			return f, true
+		}
+		return zap.Any(f.Key, out), keep
+	default:
+		// Scalars can only be matched by their key.
+		rule := r.keyRule(f.Key)
+		if rule == nil {
+			return f, true
+		}
+		enc := zapcore.NewMapObjectEncoder()
+		f.AddTo(enc)
+		out, keep := r.apply(rule.action, enc.Fields[f.Key])
+		return zap.Any(f.Key, out), keep
+	}
+}
+
+// raw redacts the encoded JSON of the fields of the yggdrasil logger, the
+// type of the field is kept.
+func (r *redactor) raw(f zapcore.Field, raw json.RawMessage) (zapcore.Field, bool) {
+	v, err := decodeJSON(raw)
+	if err != nil {
+		return f, true
+	}
+	out, keep, changed := r.redact(f.Key, v)
+	if !changed || !keep {
+		return f, keep
+	}
+	b, err := json.Marshal(out)
+	if err != nil {
+		return f, true
+	}
+	f.Interface = json.RawMessage(b)
+	return f, true
+}
+
+func (r *redactor) reflected(f zapcore.Field) (zapcore.Field, bool) {
+	w := &genericWalker{r: r, seen: make(map[seenKey]struct{})}
+	v, tagged := w.generic(reflect.ValueOf(f.Interface))
+	if w.cyclic {
+		// encoding/json cannot encode the value either, leave it to zap.
+		return f, true
+	}
+	out, keep, changed := r.redact(f.Key, v)
+	if !changed && !tagged {
+		return f, true
+	}
+	return zap.Reflect(f.Key, out), keep
+}
+
+func (r *redactor) keyRule(key string) *redactRule {
+	for i := range r.rules {
+		if r.rules[i].pattern == nil && r.rules[i].matchKey(key) {
+			return &r.rules[i]
+		}
+	}
+	return nil
+}
+
+// redact applies the rules to a generic value, maps and slices are redacted
+// in place.
+func (r *redactor) redact(key string, v interface{}) (out interface{}, keep, changed bool) {
+	if rule := r.keyRule(key); rule != nil {
+		out, keep = r.apply(rule.action, v)
+		return out, keep, true
+	}
+	switch val := v.(type) {
+	case string:
+		return r.redactString(key, val)
+	case map[string]interface{}:
+		for k, item := range val {
+			o, keep, ch := r.redact(k, item)
+			if !ch {
+				continue
+			}
+			changed = true
+			if keep {
+				val[k] = o
+			} else {
+				delete(val, k)
+			}
+		}
+		return val, true, changed
+	case []interface{}:
+		items := val[:0]
+		for _, item := range val {
+			o, keep, ch := r.redact(key, item)
+			changed = changed || ch
+			if keep {
+				items = append(items, o)
+			}
+		}
+		return items, true, changed
+	}
+	return v, true, false
+}
+
+func (r *redactor) redactString(key, s string) (interface{}, bool, bool) {
+	changed := false
+	for i := range r.rules {
+		rule := &r.rules[i]
+		if rule.pattern == nil || !rule.matchKey(key) || !rule.pattern.MatchString(s) {
+			continue
+		}
+		changed = true
+		switch rule.action {
+		case redactMask:
+			s = rule.pattern.ReplaceAllLiteralString(s, r.mask)
+		case redactHash:
+			s = rule.pattern.ReplaceAllStringFunc(s, r.hash)
+		case redactDrop:
+			return nil, false, true
+		}
+	}
+	return s, true, changed
+}
+
+func (r *redactor) apply(action string, v interface{}) (interface{}, bool) {
+	switch action {
+	case redactHash:
+		if len(r.hashKey) == 0 {
+			return r.mask, true
+		}
+		if s, ok := v.(string); ok {
+			return r.hash(s), true
+		}
+		b, _ := json.Marshal(v)
+		return r.hash(string(b)), true
+	case redactDrop:
+		return nil, false
+	default:
+		return r.mask, true
+	}
+}
+
+func (r *redactor) hash(s string) string {
+	mac := hmac.New(sha256.New, r.hashKey)
+	_, _ = mac.Write([]byte(s))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// genericWalker converts the reflected values to maps and slices the way
+// encoding/json sees them, applying the actions of the tagged struct fields.
+// The pointers, maps and slices being converted are tracked to stop at the
+// cyclic values.
+type genericWalker struct {
+	r      *redactor
+	seen   map[seenKey]struct{}
+	cyclic bool
+}
+
+type seenKey struct {
+	ptr uintptr
+	len int
+	typ reflect.Type
+}
+
+func (w *genericWalker) generic(v reflect.Value) (interface{}, bool) {
+	if !v.IsValid() || w.cyclic {
+		return nil, false
+	}
+	if isMarshaler(v) {
+		return v.Interface(), false
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice:
+		if !v.IsNil() {
+			key := seenKey{ptr: v.Pointer(), typ: v.Type()}
+			if v.Kind() == reflect.Slice {
+				key.len = v.Len()
+			}
+			if _, ok := w.seen[key]; ok {
+				w.cyclic = true
+				return nil, false
+			}
+			w.seen[key] = struct{}{}
+			defer delete(w.seen, key)
+		}
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil, false
+		}
+		return w.generic(v.Elem())
+	case reflect.Struct:
+		m := make(map[string]interface{}, v.NumField())
+		tagged := w.structFields(v, m)
+		return m, tagged
+	case reflect.Map:
+		if v.IsNil() {
+			return nil, false
+		}
+		m := make(map[string]interface{}, v.Len())
+		tagged := false
+		iter := v.MapRange()
+		for iter.Next() {
+			item, t := w.generic(iter.Value())
+			m[fmt.Sprint(iter.Key().Interface())] = item
+			tagged = tagged || t
+		}
+		return m, tagged
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && (v.IsNil() || v.Type().Elem().Kind() == reflect.Uint8) {
+			return v.Interface(), false
+		}
+		items := make([]interface{}, v.Len())
+		tagged := false
+		for i := range items {
+			var t bool
+			items[i], t = w.generic(v.Index(i))
+			tagged = tagged || t
+		}
+		return items, tagged
+	}
+	return v.Interface(), false
+}
+
+func (w *genericWalker) structFields(v reflect.Value, m map[string]interface{}) bool {
+	tagged := false
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" && !sf.Anonymous {
+			continue
+		}
+		name, opts, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if name == "-" && opts == "" {
+			continue
+		}
+		fv := v.Field(i)
+		if strings.Contains(opts, "omitempty") && fv.IsZero() {
+			continue
+		}
+		if sf.Anonymous && name == "" {
+			ev := reflect.Indirect(fv)
+			if ev.Kind() == reflect.Struct && !isMarshaler(ev) {
+				tagged = w.structFields(ev, m) || tagged
+				continue
+			}
+			if sf.PkgPath != "" {
+				continue
+			}
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		item, t := w.generic(fv)
+		tagged = tagged || t
+		if action := sf.Tag.Get(w.r.tagName); validRedactAction(action) {
+			tagged = true
+			var keep bool
+			if item, keep = w.r.apply(action, item); !keep {
+				continue
+			}
+		}
+		m[name] = item
+	}
+	return tagged
+}
+
+func isMarshaler(v reflect.Value) bool {
+	t := v.Type()
+	if t.Implements(jsonMarshalerType) || t.Implements(textMarshalerType) {
+		return v.Kind() != reflect.Ptr || !v.IsNil()
+	}
+	return false
+}
+
+func safeString(s fmt.Stringer) (str string, ok bool) {
+	defer func() {
+		if recover() != nil {
+			ok = false
+		}
+	}()
+	return s.String(), true
+}
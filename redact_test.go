@@ -0,0 +1,135 @@
+// Copyright 2022 The imkuqin-zw Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zap
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/imkuqin-zw/yggdrasil/pkg/logger"
+	"go.uber.org/zap"
+)
+
+func Test_Redactor(t *testing.T) {
+	cfg := &Config{}
+	cfg.Redact.Enable = true
+	cfg.Redact.HashKey = "k"
+	cfg.Redact.Rules = []RedactRule{
+		{Keys: []string{"*token*", "Authorization"}, Action: "mask"},
+		{Keys: []string{"password"}, Action: "drop"},
+		{Keys: []string{"user_id"}, Action: "hash"},
+		{Pattern: `1[3-9]\d{9}`, Action: "mask"},
+	}
+	lg, lines := newTestLogger(t, cfg)
+
+	type req struct {
+		Name   string `json:"name"`
+		Secret string `json:"secret" redact:"mask"`
+		Phone  string `json:"phone"`
+	}
+	lg.Zap().With(zap.String("access_token", "t1")).Info("context")
+	lg.Write(logger.LvInfo, "access",
+		"password", "p",
+		"user_id", 42,
+		"note", "call 13800138000 now",
+		"req", req{Name: "n", Secret: "s", Phone: "13900139000"},
+	)
+	logger.NewLogger(logger.LvDebug, lg).InfoField("fields",
+		logger.String("token", "abc"), logger.String("authorization", "Bearer x"), logger.Int("n", 1))
+
+	got := lines()
+	if len(got) != 3 {
+		t.Fatalf("unexpected lines: %v", got)
+	}
+	if fields := decodeLine(t, got[0]); fields["access_token"] != "***" {
+		t.Errorf("context field not masked: %s", got[0])
+	}
+
+	fields := decodeLine(t, got[1])
+	if _, ok := fields["password"]; ok {
+		t.Errorf("password not dropped")
+	}
+	r, _ := newRedactor(&RedactConfig{HashKey: "k"})
+	if fields["user_id"] != r.hash("42") {
+		t.Errorf("user_id not hashed: %v", fields["user_id"])
+	}
+	if fields["note"] != "call *** now" {
+		t.Errorf("pattern not applied: %v", fields["note"])
+	}
+	want := map[string]interface{}{"name": "n", "secret": "***", "phone": "***"}
+	req2 := fields["req"].(map[string]interface{})
+	for k, v := range want {
+		if req2[k] != v {
+			t.Errorf("req.%s: got %v, want %v", k, req2[k], v)
+		}
+	}
+
+	// The fields of the yggdrasil logger arrive as one encoded "ext" field,
+	// written as a string when json.RawMessage implements fmt.Stringer.
+	extFields, _ := decodeLine(t, got[2])["ext"].(map[string]interface{})
+	if ext, ok := decodeLine(t, got[2])["ext"].(string); ok {
+		if err := json.Unmarshal([]byte(ext), &extFields); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if extFields["token"] != "***" || extFields["authorization"] != "***" || extFields["n"] != float64(1) {
+		t.Errorf("ext not redacted: %s", got[2])
+	}
+
+	if _, err := newRedactor(&RedactConfig{Rules: []RedactRule{{Keys: []string{"id"}, Action: "hash"}}}); err == nil {
+		t.Error("hash rule accepted without a hash key")
+	}
+}
+
+func Test_RedactNilError(t *testing.T) {
+	cfg := &Config{}
+	cfg.Redact.Enable = true
+	cfg.Redact.Rules = []RedactRule{{Pattern: `secret`, Action: "mask"}}
+	lg, lines := newTestLogger(t, cfg)
+
+	var err *ptrError
+	lg.Zap().Error("failed", zap.Error(err))
+
+	got := lines()
+	if len(got) != 1 || decodeLine(t, got[0])["error"] != "<nil>" {
+		t.Fatalf("unexpected lines: %q", got)
+	}
+}
+
+type redactNode struct {
+	Name   string      `json:"name" redact:"mask"`
+	Parent *redactNode `json:"parent"`
+}
+
+func Test_RedactCyclic(t *testing.T) {
+	cfg := &Config{}
+	cfg.Redact.Enable = true
+	lg, lines := newTestLogger(t, cfg)
+
+	node := &redactNode{Name: "n"}
+	node.Parent = node
+	shared := &redactNode{Name: "s"}
+	lg.Zap().Info("cyclic", zap.Any("node", node))
+	lg.Zap().Info("shared", zap.Any("nodes", []*redactNode{shared, shared}))
+
+	got := lines()
+	if len(got) != 2 {
+		t.Fatalf("unexpected lines: %q", got)
+	}
+	b, _ := json.Marshal(decodeLine(t, got[1])["nodes"])
+	if string(b) != `[{"name":"***","parent":null},{"name":"***","parent":null}]` {
+		t.Errorf("shared values not redacted: %s", b)
+	}
+}
@@ -17,11 +17,10 @@ package zap
 import (
 	"path/filepath"
 
-	"go.uber.org/zap/zapcore"
 	"gopkg.in/natefinch/lumberjack.v2"
 )
 
-func newFileSyncer(config *FileConfig) zapcore.WriteSyncer {
+func newFileLogger(config *FileConfig) *lumberjack.Logger {
 	if config.Dir == "" {
 		config.Dir = defaultFileDir
 	}
@@ -37,12 +36,12 @@ func newFileSyncer(config *FileConfig) zapcore.WriteSyncer {
 	if config.MaxAge == 0 {
 		config.MaxAge = defaultFileMaxAge
 	}
-	return zapcore.AddSync(&lumberjack.Logger{
+	return &lumberjack.Logger{
 		Filename:   filepath.Join(config.Dir, config.Name),
 		MaxSize:    config.MaxSize,
 		MaxBackups: config.MaxBackup,
 		MaxAge:     config.MaxAge,
 		LocalTime:  config.LocalTime,
 		Compress:   config.Compress,
-	})
+	}
 }
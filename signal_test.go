@@ -0,0 +1,98 @@
+// Copyright 2022 The imkuqin-zw Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build unix
+
+package zap
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/imkuqin-zw/yggdrasil/pkg/logger"
+	"go.uber.org/zap/zapcore"
+)
+
+func waitFor(t *testing.T, cond func() bool) {
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func Test_SignalLevel(t *testing.T) {
+	cfg := &Config{}
+	cfg.Signal.Level = true
+	lg, lines := newTestLogger(t, cfg)
+	defer lg.Close()
+	lg.SetLevel(zapcore.InfoLevel)
+
+	_ = syscall.Kill(os.Getpid(), syscall.SIGUSR1)
+	waitFor(t, func() bool { return lg.Level() == zapcore.DebugLevel })
+	_ = syscall.Kill(os.Getpid(), syscall.SIGUSR2)
+	waitFor(t, func() bool { return lg.Level() == zapcore.InfoLevel })
+	_ = syscall.Kill(os.Getpid(), syscall.SIGUSR2)
+	waitFor(t, func() bool { return lg.Level() == zapcore.WarnLevel })
+
+	n := 0
+	for _, line := range lines() {
+		if decodeLine(t, line)["msg"] == "log level changed by signal" {
+			n++
+		}
+	}
+	if n != 3 {
+		t.Errorf("level changes logged %d times", n)
+	}
+}
+
+func Test_SignalReopen(t *testing.T) {
+	dir := t.TempDir()
+	defer func() {
+		mu.Lock()
+		fileLogger, fileWriteSyncer = nil, nil
+		mu.Unlock()
+	}()
+	cfg := &Config{}
+	cfg.File.Enable = true
+	cfg.File.Dir = dir
+	cfg.Signal.Reopen = true
+	lg := cfg.Build()
+	defer lg.Close()
+
+	path := filepath.Join(dir, defaultFileName)
+	lg.Write(logger.LvInfo, "before")
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	_ = syscall.Kill(os.Getpid(), syscall.SIGHUP)
+	waitFor(t, func() bool {
+		lg.Write(logger.LvInfo, "after")
+		_, err := os.Stat(path)
+		return err == nil
+	})
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "after") || strings.Contains(string(b), "before") {
+		t.Fatalf("unexpected content: %s", b)
+	}
+}
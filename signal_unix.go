@@ -0,0 +1,77 @@
+// Copyright 2022 The imkuqin-zw Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build unix
+
+package zap
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// watchSignals installs the signal handlers enabled in the config and
+// returns the function uninstalling them.
+func (lg *Logger) watchSignals() func() {
+	sigs := make([]os.Signal, 0, 3)
+	if lg.cfg.Signal.Level {
+		sigs = append(sigs, syscall.SIGUSR1, syscall.SIGUSR2)
+	}
+	if lg.cfg.Signal.Reopen {
+		sigs = append(sigs, syscall.SIGHUP)
+	}
+	if len(sigs) == 0 {
+		return func() {}
+	}
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, sigs...)
+	go func() {
+		for {
+			select {
+			case sig := <-ch:
+				lg.handleSignal(sig)
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(ch)
+		close(done)
+	}
+}
+
+func (lg *Logger) handleSignal(sig os.Signal) {
+	switch sig {
+	case syscall.SIGUSR1, syscall.SIGUSR2:
+		prev := lg.Level()
+		lv := prev - 1
+		if sig == syscall.SIGUSR2 {
+			lv = prev + 1
+		}
+		if lv < zapcore.DebugLevel || lv > zapcore.FatalLevel {
+			return
+		}
+		lg.SetLevel(lv)
+		lg.forced.Infow("log level changed by signal", "signal", sig.String(), "from", prev, "to", lv)
+	case syscall.SIGHUP:
+		if err := reopenFile(); err != nil {
+			lg.sugar.Errorw("fault to reopen log file", "error", err)
+		}
+	}
+}
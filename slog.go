@@ -0,0 +1,180 @@
+// Copyright 2022 The imkuqin-zw Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.21
+
+package zap
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type slogHandler struct {
+	core      zapcore.Core
+	name      string
+	addCaller bool
+	// groups are opened lazily, a group without attrs is omitted.
+	groups []string
+}
+
+// NewSlogHandler returns a slog.Handler writing through the sinks and the
+// levels of lg.
+func NewSlogHandler(lg *Logger) slog.Handler {
+	return &slogHandler{
+		core:      lg.sugar.Desugar().Core(),
+		name:      lg.name,
+		addCaller: lg.cfg.AddCaller,
+	}
+}
+
+func (h *slogHandler) Enabled(_ context.Context, lv slog.Level) bool {
+	return h.core.Enabled(slogLevel(lv))
+}
+
+func (h *slogHandler) Handle(_ context.Context, r slog.Record) error {
+	ent := zapcore.Entry{
+		LoggerName: h.name,
+		Time:       r.Time,
+		Level:      slogLevel(r.Level),
+		Message:    r.Message,
+	}
+	if ent.Time.IsZero() {
+		ent.Time = time.Now()
+	}
+	ce := h.core.Check(ent, nil)
+	if ce == nil {
+		return nil
+	}
+	if h.addCaller && r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		ce.Caller = zapcore.EntryCaller{
+			Defined:  true,
+			PC:       frame.PC,
+			File:     frame.File,
+			Line:     frame.Line,
+			Function: frame.Function,
+		}
+	}
+	fields := make([]zap.Field, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		fields = appendSlogAttr(fields, a)
+		return true
+	})
+	ce.Write(h.openGroups(fields)...)
+	return nil
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := make([]zap.Field, 0, len(attrs))
+	for _, a := range attrs {
+		fields = appendSlogAttr(fields, a)
+	}
+	if len(fields) == 0 {
+		return h
+	}
+	clone := *h
+	clone.core = h.core.With(h.openGroups(fields))
+	clone.groups = nil
+	return &clone
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	clone := *h
+	clone.groups = append(h.groups[:len(h.groups):len(h.groups)], name)
+	return &clone
+}
+
+func (h *slogHandler) openGroups(fields []zap.Field) []zap.Field {
+	if len(fields) == 0 || len(h.groups) == 0 {
+		return fields
+	}
+	opened := make([]zap.Field, 0, len(h.groups)+len(fields))
+	for _, group := range h.groups {
+		opened = append(opened, zap.Namespace(group))
+	}
+	return append(opened, fields...)
+}
+
+func slogLevel(lv slog.Level) zapcore.Level {
+	switch {
+	case lv < slog.LevelInfo:
+		return zapcore.DebugLevel
+	case lv < slog.LevelWarn:
+		return zapcore.InfoLevel
+	case lv < slog.LevelError:
+		return zapcore.WarnLevel
+	default:
+		return zapcore.ErrorLevel
+	}
+}
+
+func appendSlogAttr(fields []zap.Field, a slog.Attr) []zap.Field {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return fields
+	}
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return append(fields, zap.String(a.Key, a.Value.String()))
+	case slog.KindInt64:
+		return append(fields, zap.Int64(a.Key, a.Value.Int64()))
+	case slog.KindUint64:
+		return append(fields, zap.Uint64(a.Key, a.Value.Uint64()))
+	case slog.KindFloat64:
+		return append(fields, zap.Float64(a.Key, a.Value.Float64()))
+	case slog.KindBool:
+		return append(fields, zap.Bool(a.Key, a.Value.Bool()))
+	case slog.KindDuration:
+		return append(fields, zap.Duration(a.Key, a.Value.Duration()))
+	case slog.KindTime:
+		return append(fields, zap.Time(a.Key, a.Value.Time()))
+	case slog.KindGroup:
+		attrs := a.Value.Group()
+		if len(attrs) == 0 {
+			return fields
+		}
+		if a.Key == "" {
+			for _, attr := range attrs {
+				fields = appendSlogAttr(fields, attr)
+			}
+			return fields
+		}
+		return append(fields, zap.Object(a.Key, slogGroup(attrs)))
+	default:
+		if err, ok := a.Value.Any().(error); ok {
+			return append(fields, zap.NamedError(a.Key, err))
+		}
+		return append(fields, zap.Any(a.Key, a.Value.Any()))
+	}
+}
+
+type slogGroup []slog.Attr
+
+func (g slogGroup) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for _, a := range g {
+		for _, field := range appendSlogAttr(nil, a) {
+			field.AddTo(enc)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+// Copyright 2022 The imkuqin-zw Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.21
+
+package zap
+
+import (
+	"log/slog"
+	"reflect"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+type slogUser struct{ name string }
+
+func (u slogUser) LogValue() slog.Value {
+	return slog.GroupValue(slog.String("name", u.name))
+}
+
+func Test_SlogHandler(t *testing.T) {
+	root, lines := newTestLogger(t, &Config{})
+	root.SetLevel(zapcore.InfoLevel)
+	lg := root.Named("app")
+
+	sl := slog.New(NewSlogHandler(lg))
+	sl.Debug("dropped")
+	sl.With("a", 1).WithGroup("req").WithGroup("inner").Warn("served",
+		"user", slogUser{name: "bob"}, slog.Group("", "inline", true))
+	if err := lg.SetLevelFor("app", zapcore.DebugLevel); err != nil {
+		t.Fatal(err)
+	}
+	sl.Debug("kept")
+
+	entries := lines()
+	if len(entries) != 2 || decodeLine(t, entries[1])["msg"] != "kept" {
+		t.Fatalf("unexpected entries: %q", entries)
+	}
+	got := decodeLine(t, entries[0])
+	if got["lv"] != "warn" || got["Logger"] != "app" {
+		t.Fatalf("unexpected entry: %v", got)
+	}
+	want := map[string]interface{}{
+		"a": float64(1),
+		"req": map[string]interface{}{
+			"inner": map[string]interface{}{
+				"user":   map[string]interface{}{"name": "bob"},
+				"inline": true,
+			},
+		},
+	}
+	for k, v := range want {
+		if !reflect.DeepEqual(got[k], v) {
+			t.Errorf("field %s: got %v, want %v", k, got[k], v)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright 2022 The imkuqin-zw Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zap
+
+import (
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	defaultStackLevel = "error"
+
+	defaultStackKey = "stack"
+
+	// StackStyleString writes the stack as a string under the StacktraceKey
+	// of the encoders.
+	StackStyleString = "string"
+
+	// StackStyleFrames writes the stack as an array of func, file and line
+	// objects under the Key of the config.
+	StackStyleFrames = "frames"
+)
+
+type StacktraceConfig struct {
+	// Level is the lowest level capturing a stack, "error" by default.
+	Level string
+	// MaxDepth limits the number of frames, 0 means no limit.
+	MaxDepth int
+	// Filter drops the frames of the runtime, yggdrasil and zap.
+	Filter bool
+	// Style is StackStyleString, the default, or StackStyleFrames.
+	Style string
+	// Key is the key of the frames, "stack" by default.
+	Key string
+}
+
+type stackFrames []runtime.Frame
+
+func (frames stackFrames) String() string {
+	var b strings.Builder
+	for i, frame := range frames {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(frame.Function)
+		b.WriteString("\n\t")
+		b.WriteString(frame.File)
+		b.WriteByte(':')
+		b.WriteString(strconv.Itoa(frame.Line))
+	}
+	return b.String()
+}
+
+func (frames stackFrames) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for i := range frames {
+		frame := frames[i]
+		err := enc.AppendObject(zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+			enc.AddString("func", frame.Function)
+			enc.AddString("file", frame.File)
+			enc.AddInt("line", frame.Line)
+			return nil
+		}))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stackCore captures the stack of the entries at or above its level when
+// they are checked, so the stack is the one of the caller even when the
+// entry is written later by the flight recorder.
+type stackCore struct {
+	zapcore.Core
+	config   *StacktraceConfig
+	level    zapcore.Level
+	packages []string
+}
+
+func newStackCore(core zapcore.Core, config *StacktraceConfig, packages []string) (zapcore.Core, error) {
+	if config.Level == "" {
+		config.Level = defaultStackLevel
+	}
+	if config.Key == "" {
+		config.Key = defaultStackKey
+	}
+	switch config.Style {
+	case "":
+		config.Style = StackStyleString
+	case StackStyleString, StackStyleFrames:
+	default:
+		return nil, fmt.Errorf("unknown stack style %q", config.Style)
+	}
+	c := &stackCore{Core: core, config: config, packages: packages}
+	if err := c.level.UnmarshalText([]byte(config.Level)); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *stackCore) With(fields []zapcore.Field) zapcore.Core {
+	return &stackCore{Core: c.Core.With(fields), config: c.config, level: c.level, packages: c.packages}
+}
+
+func (c *stackCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if ent.Level < c.level || !c.Core.Enabled(ent.Level) {
+		return c.Core.Check(ent, ce)
+	}
+	frames := c.frames()
+	if c.config.Style == StackStyleFrames {
+		return c.Core.With([]zapcore.Field{zap.Array(c.config.Key, frames)}).Check(ent, ce)
+	}
+	ent.Stack = frames.String()
+	return c.Core.Check(ent, ce)
+}
+
+func (c *stackCore) frames() stackFrames {
+	frames := callers(2, c.packages)
+	if c.config.Filter {
+		kept := frames[:0]
+		for _, frame := range frames {
+			if !inPackages(frame, noisyPackages) {
+				kept = append(kept, frame)
+			}
+		}
+		frames = kept
+	}
+	if c.config.MaxDepth > 0 && len(frames) > c.config.MaxDepth {
+		frames = frames[:c.config.MaxDepth]
+	}
+	return frames
+}
@@ -0,0 +1,68 @@
+// Copyright 2022 The imkuqin-zw Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zap
+
+import (
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func Test_StackString(t *testing.T) {
+	obs, logs := observer.New(zapcore.DebugLevel)
+	core, err := newStackCore(sinkCore{obs}, &StacktraceConfig{Level: "warn", MaxDepth: 2}, loggerPackages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lg := zap.New(core)
+
+	lg.Info("no stack")
+	lg.Warn("stack")
+	entries := logs.All()
+	if entries[0].Stack != "" {
+		t.Errorf("unexpected stack: %s", entries[0].Stack)
+	}
+	lines := strings.Split(entries[1].Stack, "\n")
+	if len(lines) != 4 || !strings.HasSuffix(lines[0], ".Test_StackString") {
+		t.Fatalf("unexpected stack: %s", entries[1].Stack)
+	}
+}
+
+func Test_StackFrames(t *testing.T) {
+	obs, logs := observer.New(zapcore.DebugLevel)
+	core, err := newStackCore(sinkCore{obs}, &StacktraceConfig{Style: StackStyleFrames, Filter: true}, loggerPackages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zap.New(core).Error("stack")
+
+	ent := logs.All()[0]
+	frames, ok := ent.ContextMap()["stack"].([]interface{})
+	if ent.Stack != "" || !ok || len(frames) == 0 {
+		t.Fatalf("unexpected stack: %q %v", ent.Stack, ent.ContextMap())
+	}
+	first := frames[0].(map[string]interface{})
+	if !strings.HasSuffix(first["func"].(string), ".Test_StackFrames") || !strings.HasSuffix(first["file"].(string), "stack_test.go") {
+		t.Fatalf("unexpected first frame: %v", first)
+	}
+	for _, frame := range frames {
+		if fn := frame.(map[string]interface{})["func"].(string); strings.HasPrefix(fn, "runtime.") {
+			t.Errorf("runtime frame not filtered: %s", fn)
+		}
+	}
+}
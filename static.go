@@ -0,0 +1,101 @@
+// Copyright 2022 The imkuqin-zw Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zap
+
+import (
+	"os"
+	"sort"
+
+	config2 "github.com/imkuqin-zw/yggdrasil/pkg/config"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	defaultStaticServiceKey = "service"
+
+	defaultStaticVersionKey = "version"
+
+	defaultStaticHostKey = "host"
+
+	defaultStaticPidKey = "pid"
+
+	defaultStaticInstanceKey = "instance"
+
+	// omitStaticKey omits a field populated automatically.
+	omitStaticKey = "-"
+)
+
+type StaticConfig struct {
+	// ServiceKey is the key of the yggdrasil application name, "service" by default.
+	ServiceKey string
+	// VersionKey is the key of the yggdrasil application version, "version" by default.
+	VersionKey string
+	// HostKey is the key of the hostname, "host" by default.
+	HostKey string
+	// PidKey is the key of the process id, "pid" by default.
+	PidKey string
+	// InstanceKey is the key of Instance, "instance" by default.
+	InstanceKey string
+	// Instance identifies the instance of the service, omitted when empty.
+	Instance string
+	// Fields are user-defined fields.
+	Fields map[string]string
+}
+
+// staticFields returns the fields added to every entry, a key set to "-"
+// omits its field.
+func (config *StaticConfig) staticFields() []zapcore.Field {
+	key := func(key *string, def string) string {
+		if *key == "" {
+			*key = def
+		}
+		if *key == omitStaticKey {
+			return ""
+		}
+		return *key
+	}
+	fields := make([]zapcore.Field, 0, 5+len(config.Fields))
+	if k := key(&config.ServiceKey, defaultStaticServiceKey); k != "" {
+		if name := config2.Get(config2.KeyAppName).String(""); name != "" {
+			fields = append(fields, zap.String(k, name))
+		}
+	}
+	if k := key(&config.VersionKey, defaultStaticVersionKey); k != "" {
+		if version := config2.Get(config2.KeyAppVersion).String(""); version != "" {
+			fields = append(fields, zap.String(k, version))
+		}
+	}
+	if k := key(&config.HostKey, defaultStaticHostKey); k != "" {
+		if host, err := os.Hostname(); err == nil {
+			fields = append(fields, zap.String(k, host))
+		}
+	}
+	if k := key(&config.PidKey, defaultStaticPidKey); k != "" {
+		fields = append(fields, zap.Int(k, os.Getpid()))
+	}
+	if k := key(&config.InstanceKey, defaultStaticInstanceKey); k != "" && config.Instance != "" {
+		fields = append(fields, zap.String(k, config.Instance))
+	}
+	keys := make([]string, 0, len(config.Fields))
+	for k := range config.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fields = append(fields, zap.String(k, config.Fields[k]))
+	}
+	return fields
+}
@@ -0,0 +1,47 @@
+// Copyright 2022 The imkuqin-zw Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zap
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/imkuqin-zw/yggdrasil/pkg/config"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func Test_StaticFields(t *testing.T) {
+	prev := config.Get(config.KeyAppVersion).String("")
+	t.Cleanup(func() { _ = config.Set(config.KeyAppVersion, prev) })
+	if err := config.Set(config.KeyAppVersion, "2.0.0"); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &StaticConfig{HostKey: "-", Instance: "i-1", Fields: map[string]string{"region": "eu"}}
+	obs, logs := observer.New(zapcore.DebugLevel)
+	zap.New(sinkCore{obs}.With(cfg.staticFields())).Info("static")
+
+	want := map[string]interface{}{
+		"version":  "2.0.0",
+		"pid":      int64(os.Getpid()),
+		"instance": "i-1",
+		"region":   "eu",
+	}
+	if got := logs.All()[0].ContextMap(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}